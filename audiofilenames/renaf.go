@@ -58,7 +58,7 @@ func main() {
 	// ------------
 	newfns, derr  := newFilenames(trackPath, ids)
 	if (derr != nil) {
-		log.Fatal("Problem reading files at %s.\n", derr)
+		log.Fatalf("Problem reading files at %s. %s\n", trackPath, derr)
 	}
 	var proceed bool
 	if *fFlag {
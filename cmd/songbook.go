@@ -2,10 +2,16 @@ package main
 
 import(
 	"os"
+	"errors"
+	"strings"
 	"path/filepath"
 	"regexp"
 	"fmt"
 	"flag"
+	"time"
+	"slices"
+	"runtime"
+	"net/http"
 	"github.com/hermannfass/gomod/songbook"
 )
 
@@ -16,6 +22,40 @@ import(
 // (Global, but limited to this package.)
 var essenceRE = regexp.MustCompile(`\W`)
 
+// Exit codes, see »EXIT CODES« in the usage text.
+const (
+	exitError    = 1 // Any other problem
+	exitUsage    = 2
+	exitPlaylist = 3
+	exitFolder   = 4
+	exitPDF      = 5
+	exitConfig   = 6
+)
+
+// exitCode tells the exit code for an error from the songbook
+// package.
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, songbook.ErrListName):
+		return exitUsage
+	case errors.Is(err, songbook.ErrPlaylist):
+		return exitPlaylist
+	case errors.Is(err, songbook.ErrFolder):
+		return exitFolder
+	case errors.Is(err, songbook.ErrPDF):
+		return exitPDF
+	case errors.Is(err, songbook.ErrConfig):
+		return exitConfig
+	}
+	return exitError
+}
+
+// fail reports err and ends the program with the matching exit code.
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+	os.Exit(exitCode(err))
+}
+
 func main() {
 	flag.Usage = func() {
 		printUsageText()
@@ -32,54 +72,412 @@ func main() {
                   "Playlist Directory (relative to Base Path)")
 	genPdDirFlag := flag.String("gendir", "Original",
 	                "Name of directory with generic PDF files")
+	tocFlag := flag.Bool("toc", false,
+	           "Add a table of contents with page numbers")
+	pageNumFlag := flag.Bool("pagenum", false,
+	               "Stamp page numbers onto the pages")
+	stampTitleFlag := flag.Bool("stamptitle", false,
+	                  "Stamp the song title onto the pages")
+	stampPosFlag := flag.String("stamppos", "bottom",
+	                "Position of stamps: top or bottom")
+	stampSizeFlag := flag.Int("stampsize", 10, "Font size of stamps")
+	stampFrontFlag := flag.Bool("stampfront", false,
+	                  "Stamp page numbers also onto the table of contents")
+	strictFlag := flag.Bool("strict", false,
+	              "Match Playlist entries by substring only (no fuzzy match)")
+	translitFlag := flag.String("translit", "",
+	                "File with additional transliteration rules")
+	metaFlag := flag.Bool("meta", false,
+	            "Match Playlist entries also against PDF metadata")
+	recursiveFlag := flag.Bool("r", false,
+	                 "Include subdirectories of the Project Folders")
+	excludeFlag := flag.String("exclude", "",
+	               "Comma-separated patterns of subdirectories to leave out")
+	searchFlag := flag.String("search", "",
+	              "Comma-separated folders to search after the Project Folder")
+	spreadFlag := flag.Bool("spread", false,
+	              "Start songs of two or more pages on a left page")
+	imposeFlag := flag.String("impose", "",
+	              "Also write a print copy: booklet, 2up or 4up")
+	paperFlag := flag.String("paper", "",
+	             "Sheet size of the print copy, like A4 or LetterL")
+	cropMarksFlag := flag.Bool("cropmarks", false,
+	                 "Add lines to fold and cut along to the print copy")
+	partsFlag := flag.String("parts", "",
+	             "Comma-separated instrument parts, one Songbook for each")
+	fallbackFlag := flag.String("fallback", "",
+	                "Comma-separated versions to take for missing parts")
+	coverFlag := flag.Bool("cover", false,
+	             "Put a cover page in front of the Songbook")
+	setCardFlag := flag.Bool("setcard", false,
+	               "Write only a set card (large-print playlist)")
+	withCardFlag := flag.Bool("withcard", false,
+	                "Put a set card in front of the Songbook")
+	cardFlag := flag.String("card", "",
+	            "Comma-separated extras on set cards: numbers,keys,tempos,sections")
+	dryRunFlag := flag.Bool("dryrun", false,
+	              "Only report what would go into the Songbook")
+	reportFlag := flag.String("report", "",
+	              "File to write a report to (JSON if ending with .json)")
+	forceFlag := flag.Bool("force", false,
+	             "Build the Songbook even if it is up to date")
+	watchFlag := flag.Bool("watch", false,
+	             "Build the Songbook again whenever its files change")
+	intervalFlag := flag.Duration("interval", songbook.DefaultWatchInterval,
+	                "How often to look for changes with -watch")
+	allFlag := flag.Bool("all", false,
+	           "Build the Songbooks of all Playlists (of the Project given)")
+	jobsFlag := flag.Int("jobs", runtime.NumCPU(),
+	            "Number of Songbooks to build at the same time with -all")
 	flag.Parse()
 
+	// The subcommand »serve« has flags of its own, and takes all
+	// other flags after it as well.
+	serve := flag.Arg(0) == "serve" && !*allFlag
+	serveFlags := flag.NewFlagSet("serve", flag.ExitOnError)
+	serveFlags.Usage = flag.Usage
+	addrFlag := serveFlags.String("addr", ":8080",
+	            "Address to serve Songbooks at (after »serve«)")
+	if serve {
+		flag.VisitAll(func(f *flag.Flag) {
+			serveFlags.Var(f.Value, f.Name, f.Usage)
+		})
+		serveFlags.Parse(flag.Args()[1:])
+		if serveFlags.NArg() > 0 {
+			flag.Usage()
+			os.Exit(exitUsage)
+		}
+	} else if flag.NArg() != 1 && !(*allFlag && flag.NArg() == 0) {
+		flag.Usage()
+		os.Exit(exitUsage)
+	}
+
+	if *translitFlag != "" {
+		if err := songbook.ReadTransliterations(*translitFlag); err != nil {
+			fail(err)
+		}
+	}
+
 	bp := *bpFlag
 	listDir := filepath.Join(bp, *listDirFlag)
 	fmt.Printf("Base path: %s  Playlist dir: %s\n", bp, listDir)
 
 	listName := flag.Arg(0)
-	project, context := songbook.ParseListName(listName)
-
-	// Folder with the individual PDF files:
-	pdPath := filepath.Join(bp, project)
+	var project, context string
+	if !*allFlag && !serve {
+		var err error
+		project, context, err = songbook.ParseListName(listName)
+		if err != nil {
+			fail(err)
+		}
+	}
 
 	// Folder with generic PDF files:
 	genPdPath := filepath.Join(bp, *genPdDirFlag)
 
-	// Where to write the resulting songbook to:
-	outName := fmt.Sprintf("%s-%s.pdf", project, context)
-	outPath := filepath.Join(bp, outName)
+	opts := songbook.Options{
+		TOC: *tocFlag,
+		Stamp: songbook.StampOptions{
+			PageNumbers: *pageNumFlag,
+			Titles:      *stampTitleFlag,
+			Position:    *stampPosFlag,
+			FontSize:    *stampSizeFlag,
+			FrontPages:  *stampFrontFlag,
+		},
+		Strict:    *strictFlag,
+		Metadata:  *metaFlag,
+		IndexPath: filepath.Join(bp, songbook.IndexFilename),
+		Recursive: *recursiveFlag,
+		DryRun:    *dryRunFlag,
+		Force:     *forceFlag,
+		Spread:    *spreadFlag,
+		Impose: songbook.ImposeOptions{
+			Mode:      *imposeFlag,
+			Paper:     *paperFlag,
+			CropMarks: *cropMarksFlag,
+		},
+	}
+	if *excludeFlag != "" {
+		opts.Exclude = strings.Split(*excludeFlag, ",")
+	}
+	switch *imposeFlag {
+	case "", songbook.ImposeBooklet, songbook.Impose2Up, songbook.Impose4Up:
+	default:
+		fmt.Fprintf(os.Stderr, "ERROR: unknown imposition %q\n", *imposeFlag)
+		os.Exit(exitUsage)
+	}
+	opts.Cover.Enabled = *coverFlag
+	opts.SetCard.Prepend = *withCardFlag
+	for _, x := range strings.Split(*cardFlag, ",") {
+		switch strings.TrimSpace(x) {
+		case "":
+		case "numbers":
+			opts.SetCard.Numbers = true
+		case "keys":
+			opts.SetCard.Keys = true
+		case "tempos":
+			opts.SetCard.Tempos = true
+		case "sections":
+			opts.SetCard.Sections = true
+		default:
+			fmt.Fprintf(os.Stderr, "ERROR: unknown set card extra %q\n", x)
+			os.Exit(exitUsage)
+		}
+	}
+	if *fallbackFlag != "" {
+		opts.Fallback = strings.Split(*fallbackFlag, ",")
+	}
+	if *partsFlag != "" {
+		for _, p := range strings.Split(*partsFlag, ",") {
+			opts.Parts = append(opts.Parts, strings.TrimSpace(p))
+		}
+	}
+	if *searchFlag != "" {
+		opts.Search = strings.Split(*searchFlag, ",")
+	}
 
-	var messages []string
+	if serve {
+		srv := songbook.NewServer(bp, listDir, genPdPath, opts)
+		fmt.Printf("Serving Songbooks at %s (Ctrl-C to stop)\n", *addrFlag)
+		if err := http.ListenAndServe(*addrFlag, srv); err != nil {
+			fail(err)
+		}
+		return
+	}
 
+	if *watchFlag && *allFlag {
+		fmt.Fprintln(os.Stderr, "ERROR: -watch works on a single Playlist")
+		os.Exit(exitUsage)
+	}
+
+	if *setCardFlag {
+		if *allFlag {
+			fmt.Fprintln(os.Stderr, "ERROR: a set card needs a single Playlist")
+			os.Exit(exitUsage)
+		}
+		if context == "abc" {
+			fmt.Fprintln(os.Stderr, "ERROR: a set card needs a Playlist")
+			os.Exit(exitUsage)
+		}
+		listPath := filepath.Join(listDir, listName)
+		cardPath := filepath.Join(bp,
+		                          fmt.Sprintf("%s-%s-setcard.pdf", project, context))
+		fmt.Printf("Reading sequence of repertoire from: %s\n", listPath)
+		fmt.Printf("Writing set card to: %s\n", cardPath)
+		if _, err := songbook.SetCardByList(listPath, cardPath, opts.SetCard); err != nil {
+			fail(err)
+		}
+		return
+	}
+
+	// One Songbook per instrument part, or one for all:
+	parts := []string{""}
+	if *partsFlag != "" {
+		parts = strings.Split(*partsFlag, ",")
+	}
+
+	if *allFlag {
+		// project is the optional argument here, not a Playlist name.
+		lists, err := songbook.FindPlaylists(listDir, listName)
+		if err != nil {
+			fail(err)
+		}
+		var jobs []songbook.BatchJob
+		for _, list := range lists {
+			for _, part := range parts {
+				opts := opts
+				opts.Part = strings.TrimSpace(part)
+				reportPath := *reportFlag
+				if reportPath != "" {
+					reportPath = insertSuffix(reportPath,
+					                          strings.TrimSuffix(list, filepath.Ext(list)))
+				}
+				name := list
+				if opts.Part != "" {
+					name += " (" + opts.Part + ")"
+				}
+				outPath, err := songbookPath(bp, list, opts.Part)
+				if err != nil {
+					fail(err) // FindPlaylists has checked the names
+				}
+				jobs = append(jobs, songbook.BatchJob{Name: name, OutPath: outPath,
+					Build: func() (*songbook.Result, error) {
+						return build(bp, listDir, genPdPath, list, reportPath, opts)
+					}})
+			}
+		}
+		fmt.Printf("Building %d Songbook(s) from %d Playlist(s) in %s\n",
+		           len(jobs), len(lists), listDir)
+		results := songbook.RunBatch(jobs, *jobsFlag)
+		fmt.Println("\nSUMMARY:")
+		songbook.WriteBatchSummary(os.Stdout, results)
+		if err := songbook.BatchFailed(results); err != nil {
+			os.Exit(exitCode(err))
+		}
+		return
+	}
+
+	// Build the Songbook(s) and tell about missing titles etc.
+	buildParts := func() error {
+		for _, part := range parts {
+			opts.Part = strings.TrimSpace(part)
+			if opts.Part != "" {
+				fmt.Printf("\nPart: %s\n", opts.Part)
+			}
+			res, err := build(bp, listDir, genPdPath, listName, *reportFlag, opts)
+			if err != nil {
+				if res != nil {
+					printNotes(res) // Like missing titles, why nothing was there
+				}
+				return err
+			}
+			if *dryRunFlag && *reportFlag == "" {
+				fmt.Println()
+				if err := songbook.WriteReport(os.Stdout, res, false); err != nil {
+					return err
+				}
+				continue
+			}
+			printNotes(res)
+		}
+		return nil
+	}
+
+	err := buildParts()
+	if !*watchFlag {
+		if err != nil {
+			fail(err)
+		}
+		return
+	}
+
+	// Watch mode: a failed build is reported, and the next change
+	// may fix it.
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+	}
+	pdPath := filepath.Join(bp, project)
+	var watched []string
+	if context != "abc" {
+		watched = append(watched, filepath.Join(listDir, listName))
+	}
+	pc, err := songbook.ReadProjectConfig(pdPath)
+	if err != nil {
+		fail(err)
+	}
+	chain := songbook.SearchChain(pdPath, genPdPath, opts, pc.Search)
+	for _, p := range append(chain, genPdPath) {
+		if !slices.Contains(watched, p) {
+			watched = append(watched, p)
+		}
+	}
+	w := songbook.NewWatcher(watched, opts.Recursive)
+	w.Interval = *intervalFlag
+	for {
+		fmt.Printf("\nWatching for changes (Ctrl-C to stop):\n  %s\n",
+		           strings.Join(watched, "\n  "))
+		changes := w.Wait(nil)
+		fmt.Printf("\n%s Changes:\n", time.Now().Format("15:04:05"))
+		for _, c := range changes {
+			fmt.Printf("  %s\n", c)
+		}
+		if err := buildParts(); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+		}
+	}
+}
+
+// build creates the Songbook for the Playlist listName in listDir,
+// or from all PDF files of the Project for the Context »abc«, for
+// the instrument part opts.Part (if any). The Songbook is written
+// to the Base Path bp, and the report to reportPath, if set. With a
+// part, both names get the part added (see insertSuffix).
+func build(bp, listDir, genPdPath, listName, reportPath string,
+           opts songbook.Options) (*songbook.Result, error) {
+	project, context, err := songbook.ParseListName(listName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Folder with the individual PDF files:
+	pdPath := filepath.Join(bp, project)
+
+	// Where to write the resulting songbook to:
+	outPath, err := songbookPath(bp, listName, opts.Part)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Part != "" && reportPath != "" {
+		reportPath = insertSuffix(reportPath, opts.Part)
+	}
+	opts.Cover.Project, opts.Cover.Context = project, context
+
+	var res *songbook.Result
 	if (context == "abc") {
 		fmt.Printf("Collecting all PDF files from: %s\n", pdPath)
 		fmt.Println("Compiling files sorted by alphabet.")
 		fmt.Printf("Writing new songbook to: %s\n", outPath)
-		messages = songbook.SongbookByAbc(pdPath, outPath) 
+		res, err = songbook.SongbookByAbc(pdPath, outPath, opts)
 	} else {
 		// to do: If arg looks like a path, take it as listPath?
 		listPath := filepath.Join(listDir, listName)
 		fmt.Printf("Reading sequence of repertoire from: %s\n", listPath)
 		fmt.Printf("Collecting respective PDF files from: %s\n", pdPath)
 		fmt.Printf("Writing new songbook to: %s\n", outPath)
-		messages = songbook.SongbookByList(listPath, pdPath, genPdPath, outPath)
+		res, err = songbook.SongbookByList(listPath, pdPath, genPdPath, outPath,
+		                                   opts)
+	}
+	if err != nil {
+		return res, err // The Result so far, if any, tells what is missing
 	}
 
-	if len(messages) > 0 {
+	if reportPath != "" {
+		if err := songbook.SaveReport(reportPath, res); err != nil {
+			return nil, err
+		}
+		fmt.Printf("Report written to: %s\n", reportPath)
+	}
+	return res, nil
+}
+
+// printNotes shows the warnings and missing titles of res.
+func printNotes(res *songbook.Result) {
+	if messages := res.Messages(); len(messages) > 0 {
 		fmt.Println("\nNOTE:")
 		for _, m := range messages {
 			fmt.Println(m)
 		}
 	}
+}
+
+// songbookPath returns the path of the Songbook for the Playlist
+// listName and the instrument part (if any) in the Base Path bp, like
+// »TheKeltners-tour.pdf« or »TheKeltners-tour-bass.pdf«. Only the
+// Project and the Context make the name, see ParseListName.
+func songbookPath(bp, listName, part string) (string, error) {
+	project, context, err := songbook.ParseListName(listName)
+	if err != nil {
+		return "", err
+	}
+	outPath := filepath.Join(bp, fmt.Sprintf("%s-%s.pdf", project, context))
+	if part != "" {
+		outPath = insertSuffix(outPath, part)
+	}
+	return outPath, nil
+}
 
+// insertSuffix adds a hyphen and suffix to the name of an output
+// file: with the part »bass«, »TheKeltners-tour.pdf« becomes
+// »TheKeltners-tour-bass.pdf«.
+func insertSuffix(path, suffix string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + suffix + ext
 }
 
 
 
 func printUsageText() {
-	fmt.Println(`
+	fmt.Print(`
  /////////////
    songbook  
 /////////////
@@ -91,7 +489,8 @@ orchestra, or a specific concert, into a »Songbook«, i.e. a single
 PDF file.
 For that, PDF files specific to the Project need to be kept in one
 directory, called the »Project Folder«. A Project Folder may have
-subdirectories, but they are ignored. 
+subdirectories, but they are ignored unless the flag -r is set (see
+»Subdirectories« below).
 The order in which the songs are added to a Songbook is defined
 either by a »Playlist« or by alphabet, as described in the next
 section. File organization details see further below and under
@@ -115,13 +514,37 @@ The content (which pieces) and the order can be defined two ways:
    Playlist entries:
    Just list the songs that should be included in the Songbook one
    song per line. The entry for a song does not need to match the
-   PDF filename exactly, but it must be included in the PDF filename.
-   Matching Playlist entries against PDF filenames is case-insensitive
-   and ignores spaces and non-alphanumeric character.
+   PDF filename exactly. Matching Playlist entries against PDF
+   filenames is case-insensitive and ignores spaces and
+   non-alphanumeric characters. Letters of any script (Cyrillic,
+   Greek, Japanese, ...) count; an entry without any letters or
+   digits matches nothing. Umlauts and accents may be spelled out or
+   left away: »Über den Wolken« matches »UeberDenWolken.pdf« as well
+   as »Uber-den-Wolken.pdf«, and »Café« matches »Cafe.pdf«.
+   Matching also tolerates small typos, a different word order, and
+   words in the filename that are not in the Playlist entry.
    For example, the PDF file »BeautifulNoise-NeilDiamond-guitar.pdf«
-   will match a Playlist entry like »Beautiful Noise« or
-   »Beautiful Noise (Neil Diamond)«. Note that the Playlist entry
-   needs to be part of the filename, not vice versa.
+   will match a Playlist entry like »Beautiful Noise«,
+   »Beautiful Noise (Neil Diamond)«, or »Beautifull Noise«.
+   Words in the Playlist entry that are not in the filename are not
+   tolerated: »Yesterday (Beatles)« does not match »Yesterday.pdf«;
+   leave them away or use an alias (see »Aliases«).
+   Each file gets a score for how well it matches, and the best file
+   is taken; if several files match equally well, the one whose
+   title (up to the first hyphen) the entry covers most is taken, so
+   »Let It Be« takes »LetItBe.pdf« rather than »LetItBeMe.pdf«.
+   If no file matches well enough, the closest candidates are listed
+   at the end of the run.
+
+   Page ranges:
+   To take only some pages of a PDF file, add them in brackets at
+   the end of the Playlist entry: »Autumn Leaves [3]« takes just the
+   lead sheet on page 3 of a full score, »Medley [2-4]« pages 2 to 4,
+   and »Medley [1,3-4]« pages 1, 3 and 4. Only these pages go into
+   the Songbook; the PDF file itself is not changed. Brackets with
+   anything but page numbers, like in »Hey Jude [Live]«, are part of
+   the title. If the PDF file does not have the pages asked for, the
+   song is left out and listed as missing at the end of the run.
 
    Avoid amiguity:
    When entering songs in a Playlist, make sure they point to only
@@ -131,15 +554,52 @@ The content (which pieces) and the order can be defined two ways:
    specific enough. If there are two files for Gershwin's »Summertime«,
    like »Summertime-BigBrother.pdf« and »Summertime-Holiday.pdf«, a
    Playlist entry like just »Summertime« is not clear, but for example
-   »Summertime (Holiday)« will be clear.
-   Also note that a Playlist entry like »Summertime (Billie Holiday)«
-   will not match any of the two PDF files: The system removes spaces
-   and non-alphanumeric characters from the Playlist entry and from all
-   filenames, downcases the results and then looks for a stripped
-   filename that includes the stripped Playlist entry,
-   »summertimebillieholiday«. Neither »summertimeholday.pdf« nor
-   »summertimebigbrother.pdf« includes this string, so there will be
-   no result, i.e. no PDF file for this song will go to the Songbook.
+   »Summertime (Holiday)« or »Summertime (Billie Holiday)« will be
+   clear. If several files match equally well, all of them go into
+   the Songbook and a warning is shown at the end of the run.
+
+   Strict matching:
+   With the flag -strict, a Playlist entry must be part of the
+   filename: The system removes spaces and non-alphanumeric
+   characters from the Playlist entry and from all filenames,
+   downcases the results and then looks for stripped filenames that
+   include the stripped Playlist entry. All of these files go into
+   the Songbook. A Playlist entry like »Summertime (Billie Holiday)«
+   will then not match »Summertime-Holiday.pdf«, because
+   »summertimeholiday« does not include »summertimebillieholiday«.
+
+   Aliases:
+   Songs that go by several names can get aliases. Each Project
+   Folder and the Cross-Project Folder may contain a text file named
+   »aliases.txt« with one alias per line, pointing either to the
+   canonical title or to a specific PDF file:
+     BEG = Brown Eyed Girl
+     Halleluja = Hallelujah-Cohen.pdf
+   A Playlist entry is looked up in the aliases of a folder before it
+   is matched against the PDF files there. With -r, a PDF file in a
+   subdirectory may be named with or without its path
+   (»Ballads/Hallelujah-Cohen.pdf« or »Hallelujah-Cohen.pdf«).
+   Aliases defined twice with different targets, aliases pointing to
+   missing PDF files, and aliases of the Project Folder that were not
+   used for the Songbook are listed at the end of the run.
+
+   Metadata:
+   Many PDF files from publishers carry the title and the composer of
+   the piece in their document properties. With the flag -meta,
+   Playlist entries are matched against these as well, so
+   »Autumn Leaves (Kosma)« finds »AL-0815.pdf« if its metadata says
+   »Autumn Leaves« by »Joseph Kosma«. The metadata comes from the
+   Library Index (see below).
+
+   Subdirectories:
+   With the flag -r, PDF files in subdirectories of the Project
+   Folder (and of the Cross-Project Folder) are included, so a big
+   Project Folder can be organized into »Ballads/«, »Rock/« etc.
+   Playlist entries are matched against the path below the Project
+   Folder, thus »Yesterday (Ballads)« matches »Ballads/Yesterday.pdf«.
+   The output tells from which subdirectory a file was taken.
+   Subdirectories can be left out with the flag -exclude and a
+   comma-separated list of patterns, like »-exclude Archive,Old*«.
 
    Cross-Project PDF files:
    If no file in the Project Folder seems to match the name of the
@@ -148,10 +608,59 @@ The content (which pieces) and the order can be defined two ways:
    multiple Projects. If the file is taken from there, this is
    indicated in the output during the run.
 
+   Search Chain:
+   The Project Folder and the Cross-Project Folder are just the
+   default »Search Chain«: a list of folders that are searched for a
+   song one after the other, until one has a matching file. A
+   Project can define its own Search Chain in a file »songbook.yaml«
+   in its Project Folder:
+     search:
+       - OtherBand
+       - Original
+       - /home/me/library
+   The Project Folder always comes first. Relative folder names are
+   relative to the Base Path. The flag -search with a comma-separated
+   list of folders, like »-search OtherBand,Original«, overrides the
+   list in the file. The output tells on which level of the Search
+   Chain each song was found. Folders that do not exist are skipped
+   with a warning.
+
+   Structured Playlists:
+   Instead of a text file, a Playlist may be a YAML file (suffix
+   ».yaml« or ».yml«) or a JSON file (suffix ».json«) with a list
+   of entries. An entry is either just a title or a set of fields:
+     title:    the song title, matched like a line of a text Playlist
+     file:     a specific PDF file to use, no matching by title
+     pages:    only these pages of the PDF file, like »2-3« or »1,4«
+     project:  look in the Folder of this Project instead (first)
+     notes:    free-form notes, shown when building the Songbook
+     section:  a heading instead of a song, see »Sections« below
+     key:      the key of the song, for set cards (see below)
+     tempo:    the tempo of the song, for set cards
+   Example »CoolBand-Concert20250913.yaml«:
+     - Autumn Leaves
+     - title: Summertime
+       file: Summertime-Holiday.pdf
+       pages: 2-3
+     - title: The Boxer
+       project: RockstarSummit_2025
+       notes: Capo 2
+   A single page may also be given as a number (»pages: 3«). An entry
+   whose project has no Folder is listed as missing.
+
    Comments:
    In case you want to add comments to your Playlist (or make the
    system temporarily ignoring individual entries in the Playlist),
    just prepend the respective lines with a hash symbol (#).
+
+   Sections:
+   A line starting with two hash symbols is a heading, like
+   »## Set 2«, »## Pause« or »## Encores«. It starts a new section of
+   the Songbook with a divider page that shows the heading in large
+   type. In the bookmarks, the songs of a section are grouped under
+   the heading, and the table of contents lists the headings in bold.
+   In a structured Playlist, an entry like »- section: Set 2« does
+   the same. No PDF files for the divider pages are needed.
    
    Example:
    Assuming one Playlist for project »CoolBand« is called
@@ -171,6 +680,241 @@ The content (which pieces) and the order can be defined two ways:
    This will combine all PDF files in the Project Folder »CoolBand«
    into one PDF file named »CoolBand-abc.pdf«.
 
+TABLE OF CONTENTS
+
+   With the flag -toc the Songbook starts with one or more pages
+   listing every song with the page it starts on. For a Songbook
+   from a Playlist, the songs are listed with their Playlist entries,
+   for a Songbook by alphabet with titles derived from the PDF
+   filenames (»BeautifulNoise-NeilDiamond.pdf« is listed as
+   »Beautiful Noise - Neil Diamond«).
+   Titles in Latin, Greek and Cyrillic letters are shown on the
+   table of contents and all other pages the program writes (stamps,
+   dividers, set cards, covers). For other scripts (like Japanese),
+   there is no font; such titles are left blank, with a warning.
+
+BOOKMARKS
+
+   Every Songbook has a bookmark (outline entry) for each song,
+   pointing to its first page, so PDF readers and tablet apps can
+   jump directly to a song. The bookmarks are labelled like the
+   entries in the table of contents.
+
+PAGE NUMBERS AND TITLES
+
+   With the flag -pagenum every page of sheet music gets its page
+   number stamped in the outer corner (right on odd pages, left on
+   even pages, like in a printed book), with -stamptitle the title of
+   the song on the other side. The numbers are the page numbers of
+   the PDF file, thus the same as in the table of contents.
+   By default the stamps go into the footer; -stamppos top moves them
+   into the header. The table of contents gets no page numbers unless
+   -stampfront is set.
+
+TWO-PAGE SPREADS
+
+   On a two-page display or in a binder, a song of two pages that
+   starts on a right-hand page needs a page turn in the middle. With
+   the flag -spread, a blank page is inserted before such a song, so
+   every song of two or more pages starts on a left-hand page. Like
+   in a book, page 1 is a right-hand page, so left-hand pages have
+   even numbers. Songs are never reordered. The output (and the
+   report, see below) tells how many blank pages were added.
+
+PRINT COPIES
+
+   With the flag -impose, a print-ready copy of the Songbook is
+   written next to it, with the pages arranged on sheets:
+     booklet  two pages per side, in the order for folding and
+              stapling in the middle (A4 sheets give an A5 booklet),
+              named like »TheKeltners-tour-booklet.pdf«
+     2up      two pages per sheet in reading order
+     4up      four pages per sheet in reading order
+   The sheet size is A4 unless given with the flag -paper, like
+   »-paper Letter« (add L for landscape, like »A3L«). The flag
+   -cropmarks adds lines to fold and cut along.
+
+TRANSLITERATION
+
+   Umlauts are spelled out by the German rules (ä = ae, ö = oe,
+   ü = ue, ß = ss). More rules can be given in a text file with one
+   rule per line, like »ø=oe« or »å=aa«, passed with the flag
+   -translit. Lines starting with # are ignored.
+
+LIBRARY INDEX
+
+   What the application needs to know about the PDF files (page
+   counts and page sizes, metadata, and the prepared filenames for
+   matching) is kept in the file ».songbook-index.json« in the Base
+   Path. On every run, only PDF files that are new or have changed
+   (by size or modification time) are read again, which saves time
+   with large libraries, e.g. on a network share. Deleting the file
+   is safe; it is rebuilt on the next run.
+
+INSTRUMENT PARTS
+
+   Sheet music for the different instruments of a song is told apart
+   by the part as the last hyphen-separated piece of the filename,
+   like »TheBoxer-guitar.pdf«, »TheBoxer-bass.pdf«, »TheBoxer-keys.pdf«.
+   With the flag -parts and a comma-separated list of parts, like
+   »-parts bass,keys«, one Songbook is created for each part, named
+   like »TheKeltners-tour-bass.pdf«. A Playlist entry like »The Boxer«
+   then takes only the file of that part. If a song has no file for
+   the part, the »full« version is taken, or else the »lead« version,
+   or else a file without any part, like »Yesterday.pdf«. The flag
+   -fallback replaces the list of these versions, like
+   »-fallback score,full«. The output and the report tell which
+   version was taken.
+   To tell parts from other suffixes (like in
+   »Summertime-Holiday.pdf«), a file is a part file only if the last
+   piece is one of the parts asked for, one of the fallback versions,
+   or one of the parts listed in the file »songbook.yaml« in the
+   Project Folder:
+     parts:
+       names: [guitar, bass, keys, drums]
+       fallback: [full, lead]
+
+COVER PAGE
+
+   With the flag -cover, the Songbook gets a cover page that shows
+   the Project and the Context from the name of the Playlist, the
+   date, and the number of songs. To give all Songbooks of a Project
+   the same cover (without the flag), add to the file »songbook.yaml«
+   in its Project Folder (see »Search Chain« above):
+     cover:
+       enabled: true
+       title: The Keltners
+       subtitle: Live and Unplugged
+       logo: logo.png
+       dateFormat: 02.01.2006
+   All of these are optional. Title and subtitle replace the Project
+   and the Context. The logo is a PNG or JPEG file in the Project
+   Folder. The date format is a Go time layout (the reference date
+   is the 2nd of January 2006), by default like »13 September 2025«.
+
+SET CARDS
+
+   A »Set Card« is the Playlist printed in large type, e.g. to be
+   taped to the floor for the drummer. With the flag -setcard, only
+   the set card is written, like »TheKeltners-tour-setcard.pdf«; no
+   PDF files are needed for it. With the flag -withcard, the set card
+   is put in front of the Songbook (and its table of contents).
+   The flag -card adds extras to the set card, as a comma-separated
+   list of:
+     numbers   number the songs
+     keys      the key of each song (field »key« of a structured
+               Playlist)
+     tempos    the tempo of each song (field »tempo«)
+     sections  the section headings, see »Sections« above
+   Example: »-setcard -card numbers,keys,sections«
+   The type is as large as possible while the whole set fits onto
+   one page. Very long sets take more than one page rather than
+   getting too small to read.
+
+DRY RUN AND REPORT
+
+   With the flag -dryrun, the Playlist is resolved as usual and the
+   pages are counted, but no Songbook is written. Instead, a report
+   lists each song with its pages in the Songbook, the level of the
+   Search Chain and the PDF file(s) it was found in, followed by
+   missing and ambiguous titles and other warnings.
+   The flag -report writes such a report to a file, with or without
+   -dryrun. If the filename ends with ».json«, the report is JSON,
+   e.g. for scripts that check Playlists:
+     songbook -dryrun -report /tmp/check.json TheKeltners-tour.txt
+
+SERVING SONGBOOKS
+
+   With »serve« instead of a Playlist name, the Songbooks are made
+   available over HTTP, e.g. from a laptop to the tablets of the band
+   on the Wi-Fi of the venue:
+     songbook -toc -pagenum serve
+   Then open »http://<laptop>:8080/« in the browser of the tablet. It
+   lists the Projects with their Playlists (and a Songbook of all
+   songs of a Project, like with »TheKeltners-abc«). A Playlist opens
+   a page with a button to download the Songbook, the titles without
+   PDF file and other warnings, and the songs. If »songbook.yaml« in
+   the Project Folder names the instrument parts, there are links to
+   the Songbook of each part.
+   The Songbook is built whenever it is opened, with the flags given
+   (like -toc), so it is always up to date with the Playlist and the
+   PDF files. As with the command line, it is written to the Base Path
+   and not built again if nothing has changed.
+   The flag -addr after »serve« sets the address and port to listen
+   at, like »songbook serve -addr :9000«. The flags for the Songbooks
+   may go before or after »serve«. Anyone on the network can read the Songbooks, so
+   better do this on trusted networks only.
+
+WATCH MODE
+
+   With the flag -watch, the Songbook is built, and then built again
+   whenever anything changes that it is made of: the Playlist, the
+   Project Folder (including »songbook.yaml« and aliases), the
+   folders of the Search Chain and the Folder with generic PDF files
+   (»Original«). The program keeps running until stopped with Ctrl-C.
+   Each time, it prints what has changed, like
+     changed: /home/me/sheetmusic/playlists/TheKeltners-tour.txt
+     added: /home/me/sheetmusic/TheKeltners/Yesterday.pdf
+   and the result of the build. If a build fails, the error is shown,
+   and the next change may fix it.
+   The files are looked at every second, or as often as given with
+   the flag -interval (like »-interval 5s«). As saving or copying
+   files often takes several steps, the Songbook is built only when
+   nothing has changed for two seconds. Hidden files are ignored.
+
+INCREMENTAL BUILDS
+
+   Next to each Songbook, a hidden file like ».TheKeltners-tour.pdf.json«
+   records what it was built from: the Playlist, the PDF files of the
+   songs, the options, and what is on generated pages (cover, set
+   card, table of contents). If none of this has changed, and the
+   Songbook (and its print copy) are still as they were written, the
+   Songbook is not built again; the output says »Up to date«.
+   A change is noticed by the content of the files, so a file that is
+   only touched (e.g. by a git checkout) does not count. As the cover
+   shows the date, a Songbook with a cover is built again on the next
+   day. The flag -force builds the Songbook anyway.
+   Together with -all, this makes it cheap to rebuild all Songbooks
+   whenever anything might have changed, e.g. from a cron job or a git
+   hook:
+     songbook -all -toc -pagenum
+
+BATCH MODE
+
+   With the flag -all, the Songbooks of all Playlists in the
+   Playlist Directory are built in one run. Instead of a Playlist
+   name, a Project may be given to build only the Playlists of this
+   Project:
+     songbook -all              (all Playlists)
+     songbook -all TheKeltners  (TheKeltners-tour.txt etc.)
+   Several Songbooks are built at the same time, as many as the
+   computer has processor cores, or as many as given with the flag
+   -jobs. A Songbook that fails does not stop the others. At the
+   end, a summary lists each Songbook with its missing titles, and
+   the Songbooks that failed. With -parts, one Songbook per Playlist
+   and part is built. With -report, the name of the Playlist is
+   added to the name of each report, like »check-TheKeltners-tour.json«.
+   The output of Songbooks built at the same time is mixed, so
+   better rely on the summary.
+   A Songbook is named by the Project and the Context only, so
+   Playlists like »TheKeltners-tour.txt« and »TheKeltners-tour-2025.txt«
+   would write the same file. Such Playlists are not built but listed
+   as failed in the summary; rename one of them.
+
+EXIT CODES
+
+   0  The Songbook was written. Titles without PDF file are listed
+      at the end of the run, but do not count as failure.
+      In batch mode: all Songbooks were written. Otherwise the code
+      tells why the first Songbook (in the summary) failed.
+   1  Any other error
+   2  Wrong call, e.g. a Playlist name without Project and Context
+   3  The Playlist cannot be read or has invalid entries
+   4  A Project Folder or the Cross-Project Folder cannot be read
+   5  A PDF file cannot be read, or the Songbook cannot be written
+   6  A configuration file (aliases, transliterations, songbook.yaml,
+      Library Index) is broken
+
 FILE NAMING AND LOCALIZATION
 
    In general, it is good style (not only for this application) to
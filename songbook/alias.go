@@ -0,0 +1,170 @@
+package songbook
+
+import(
+	"fmt"
+	"bufio"
+	"os"
+	"sort"
+	"maps"
+	"slices"
+	"strings"
+	"path/filepath"
+)
+
+// AliasFilename is the name of the alias file in a folder with PDF
+// files.
+const AliasFilename = "aliases.txt"
+
+// Aliases holds the alternative titles defined in the alias file of
+// a folder with PDF files. Each line of the file maps an alias to
+// either a canonical title or a PDF filename (ending with ».pdf«):
+//
+//	BEG = Brown Eyed Girl
+//	Halleluja = Hallelujah-Cohen.pdf
+//
+// Aliases are compared by their essence, like titles. The nil
+// *Aliases (no alias file) has no aliases.
+type Aliases struct {
+	path      string
+	aliases   map[string]string // Alias as written, by its essence
+	targets   map[string]string // Target, by the essence of the alias
+	used      map[string]bool   // Essences of aliases used so far
+	conflicts []string
+}
+
+// ReadAliases reads the alias file in the folder dir. It returns
+// nil (and no error) if there is no alias file. Lines that are
+// empty or start with # are ignored. An alias defined twice with
+// different targets is a conflict: the first definition wins, and
+// the conflict is reported by Messages.
+func ReadAliases(dir string) (*Aliases, error) {
+	path := filepath.Join(dir, AliasFilename)
+	fh, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConfig, err)
+	}
+	defer fh.Close()
+	a := &Aliases{path: path, aliases: map[string]string{},
+	              targets: map[string]string{}, used: map[string]bool{}}
+	scanner := bufio.NewScanner(fh)
+	for scanner.Scan() {
+		tl := strings.TrimSpace(scanner.Text())
+		if tl == "" || strings.HasPrefix(tl, "#") {
+			continue
+		}
+		alias, target, ok := strings.Cut(tl, "=")
+		alias, target = strings.TrimSpace(alias), strings.TrimSpace(target)
+		key := essence(alias)
+		if !ok || key == "" || target == "" {
+			a.conflicts = append(a.conflicts,
+			                     fmt.Sprintf("Invalid alias line in %s: %s", path, tl))
+			continue
+		}
+		if prev, ok := a.targets[key]; ok {
+			if prev != target {
+				a.conflicts = append(a.conflicts,
+				    fmt.Sprintf("Conflicting aliases in %s: %s = %s, %s = %s",
+				                path, a.aliases[key], prev, alias, target))
+			}
+			continue
+		}
+		a.aliases[key] = alias
+		a.targets[key] = target
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrConfig, path, err)
+	}
+	return a, nil
+}
+
+// Resolve looks up title among the aliases. It returns the target
+// (canonical title or PDF filename) and whether the title is an
+// alias at all. A resolved alias counts as used.
+func (a *Aliases) Resolve(title string) (string, bool) {
+	if a == nil {
+		return "", false
+	}
+	key := essence(title)
+	target, ok := a.targets[key]
+	if ok {
+		a.used[key] = true
+	}
+	return target, ok
+}
+
+// Unused returns the aliases that have not been resolved so far,
+// sorted by alphabet.
+func (a *Aliases) Unused() []string {
+	if a == nil {
+		return nil
+	}
+	var unused []string
+	for key, alias := range a.aliases {
+		if !a.used[key] {
+			unused = append(unused, alias)
+		}
+	}
+	sort.Strings(unused)
+	return unused
+}
+
+// Messages returns the problems with the alias file: conflicting
+// or invalid lines, aliases that point to a PDF file not in the
+// folder (names being the PDF files there) or, by its bare name, to
+// files in several subdirectories, and, if withUnused is set, the
+// aliases that have not been used.
+func (a *Aliases) Messages(names []string, withUnused bool) []string {
+	if a == nil {
+		return nil
+	}
+	messages := append([]string{}, a.conflicts...)
+	keys := slices.Sorted(maps.Keys(a.targets))
+	for _, key := range keys {
+		target := a.targets[key]
+		if !isPdfName(target) {
+			continue
+		}
+		switch files := aliasFiles(target, names); {
+		case len(files) == 0:
+			messages = append(messages,
+			    fmt.Sprintf("Alias %s in %s points to missing file %s",
+			                a.aliases[key], a.path, target))
+		case len(files) > 1:
+			messages = append(messages,
+			    fmt.Sprintf("Alias %s in %s points to several files %s: %s",
+			                a.aliases[key], a.path, target,
+			                strings.Join(files, ", ")))
+		}
+	}
+	if unused := a.Unused(); withUnused && len(unused) > 0 {
+		messages = append(messages,
+		    fmt.Sprintf("Unused aliases in %s: %s", a.path,
+		                strings.Join(unused, ", ")))
+	}
+	return messages
+}
+
+// aliasFiles returns the PDF files among names that the alias
+// target (a PDF filename) points to: the file of this name, or, in
+// a folder read with its subdirectories (see OpenFolder), the files
+// of this name in any of them, like »Ballads/Hallelujah.pdf« for
+// »Hallelujah.pdf«.
+func aliasFiles(target string, names []string) []string {
+	if slices.Contains(names, target) {
+		return []string{target}
+	}
+	var files []string
+	for _, n := range names {
+		if filepath.Base(n) == target {
+			files = append(files, n)
+		}
+	}
+	return files
+}
+
+// isPdfName reports whether s is a PDF filename (by its suffix).
+func isPdfName(s string) bool {
+	return strings.EqualFold(filepath.Ext(s), ".pdf")
+}
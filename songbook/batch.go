@@ -0,0 +1,176 @@
+package songbook
+
+import(
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"slices"
+	"strings"
+	"path/filepath"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+// playlistExts are the suffixes of the files that FindPlaylists
+// takes as Playlists (see ReadEntries).
+var playlistExts = []string{".txt", ".yaml", ".yml", ".json"}
+
+// FindPlaylists returns the names of all Playlists in the folder
+// listDir, sorted by name. With project set, only Playlists of this
+// Project are returned. Files whose names do not parse as Playlist
+// names (see ParseListName) are skipped.
+func FindPlaylists(listDir, project string) ([]string, error) {
+	entries, err := os.ReadDir(listDir)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFolder, err)
+	}
+	var names []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		ext := strings.ToLower(filepath.Ext(name))
+		if !slices.Contains(playlistExts, ext) {
+			continue
+		}
+		p, _, err := ParseListName(name)
+		if err != nil {
+			fmt.Printf("Skipping %s: %s\n", name, err)
+			continue
+		}
+		if project != "" && p != project {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names, nil // os.ReadDir sorts by name
+}
+
+// BatchJob is one Songbook to be built by RunBatch: its Name (for
+// the summary, like »TheKeltners-tour.txt« or »TheKeltners-tour.txt
+// (bass)«), the file it is written to, and the function that builds
+// it.
+type BatchJob struct {
+	Name    string
+	OutPath string
+	Build   func() (*Result, error)
+}
+
+// BatchResult is the outcome of a BatchJob: the Result of the
+// build, or the error that made it fail.
+type BatchResult struct {
+	Name   string
+	Result *Result
+	Err    error
+}
+
+// RunBatch builds the Songbooks of jobs, up to workers at a time,
+// and returns the outcomes in the order of jobs. A job that fails
+// does not stop the others. Jobs that would write the same file
+// (like for »Band-gig.txt« and »Band-gig-2025.txt«, see
+// ParseListName) are not run but fail, as they would spoil each
+// other's Songbook.
+func RunBatch(jobs []BatchJob, workers int) []BatchResult {
+	// pdfcpu loads its configuration on first use, which must not
+	// happen in several jobs at once.
+	model.NewDefaultConfiguration()
+	results := make([]BatchResult, len(jobs))
+	byPath := map[string][]string{} // Names of the jobs for each file
+	for _, j := range jobs {
+		byPath[j.OutPath] = append(byPath[j.OutPath], j.Name)
+	}
+	var run []int // Jobs to run
+	for i, j := range jobs {
+		if names := byPath[j.OutPath]; len(names) > 1 {
+			results[i] = BatchResult{Name: j.Name,
+				Err: fmt.Errorf("%w: %s would all write %s", ErrListName,
+				                strings.Join(names, ", "), j.OutPath)}
+			continue
+		}
+		run = append(run, i)
+	}
+	todo := make(chan int)
+	var wg sync.WaitGroup
+	for range max(1, min(workers, len(run))) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range todo {
+				results[i] = runJob(jobs[i])
+			}
+		}()
+	}
+	for _, i := range run {
+		todo <- i
+	}
+	close(todo)
+	wg.Wait()
+	return results
+}
+
+// runJob builds the Songbook of job. A panic (e.g. from a broken
+// PDF file) fails only this job.
+func runJob(job BatchJob) (br BatchResult) {
+	br.Name = job.Name
+	defer func() {
+		if r := recover(); r != nil {
+			br.Err = fmt.Errorf("%w: %v", ErrPDF, r)
+		}
+	}()
+	br.Result, br.Err = job.Build()
+	return br
+}
+
+// BatchFailed returns the first error among results, or nil if all
+// Songbooks were built.
+func BatchFailed(results []BatchResult) error {
+	for _, r := range results {
+		if r.Err != nil {
+			return r.Err
+		}
+	}
+	return nil
+}
+
+// WriteBatchSummary writes a summary of a batch run to w: one line
+// per Songbook with its pages and songs (and whether it was up to
+// date), or the error it failed with, and below it the missing
+// titles and the warnings (also of a failed Songbook, as far as
+// they are known), followed by the totals.
+func WriteBatchSummary(w io.Writer, results []BatchResult) {
+	var built, current, failed, missing int
+	for _, r := range results {
+		res := r.Result
+		switch {
+		case r.Err != nil:
+			failed++
+			fmt.Fprintf(w, "FAILED %s: %s\n", r.Name, r.Err)
+			if res == nil {
+				continue
+			}
+		case res.UpToDate:
+			current++
+		default:
+			built++
+		}
+		missing += len(res.Missing)
+		if r.Err == nil {
+			state := ""
+			if res.UpToDate {
+				state = " (up to date)"
+			}
+			fmt.Fprintf(w, "%s: %s%s, %d page(s), %d song(s), %d missing\n",
+			            r.Name, res.OutPath, state, res.Pages, songCount(res.Songs),
+			            len(res.Missing))
+		}
+		for _, m := range res.Missing {
+			fmt.Fprintf(w, "  %s\n", m)
+		}
+		for _, m := range res.Warnings {
+			fmt.Fprintf(w, "  %s\n", m)
+		}
+	}
+	fmt.Fprintf(w, "\n%d Songbook(s) built, %d up to date, %d failed, " +
+	            "%d title(s) missing\n", built, current, failed, missing)
+}
@@ -0,0 +1,45 @@
+package songbook
+
+import(
+	"fmt"
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+)
+
+// songBookmarks returns one bookmark per song, labelled with the
+// song title and pointing to its first page. The bookmark of a
+// divider is the parent of the bookmarks of the songs in its
+// section; songs before the first divider get top-level bookmarks.
+// The songs' StartPage must be set, see numberPages.
+func songBookmarks(songs []Song) []pdfcpu.Bookmark {
+	var bms []pdfcpu.Bookmark
+	var inSection bool
+	for _, s := range songs {
+		if s.Pages == 0 {
+			continue
+		}
+		bm := pdfcpu.Bookmark{Title: s.Title, PageFrom: s.StartPage}
+		if inSection && !s.Divider {
+			parent := &bms[len(bms) - 1]
+			parent.Kids = append(parent.Kids, bm)
+			continue
+		}
+		bms = append(bms, bm)
+		inSection = inSection || s.Divider
+	}
+	return bms
+}
+
+// AddBookmarks replaces the outline of the PDF file at path with
+// the bookmarks bms. Bookmarks that came with the individual sheet
+// music files are dropped this way.
+func AddBookmarks(path string, bms []pdfcpu.Bookmark) error {
+	if len(bms) == 0 {
+		return nil
+	}
+	fmt.Printf("Adding %d bookmark(s)\n", len(bms))
+	if err := api.AddBookmarksFile(path, path, bms, true, nil); err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrPDF, path, err)
+	}
+	return nil
+}
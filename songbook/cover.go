@@ -0,0 +1,134 @@
+package songbook
+
+import(
+	"fmt"
+	"os"
+	"time"
+	"strings"
+	"path/filepath"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"github.com/pdfcpu/pdfcpu/pkg/font"
+)
+
+// Layout of the cover page.
+const (
+	coverTitleSize    = 40
+	coverSubtitleSize = 24
+	coverInfoSize     = 14
+	coverLogoSize     = 240.0 // Largest width and height of the logo
+	defaultDateFormat = "2 January 2006"
+)
+
+// CoverOptions asks for a cover page in front of a songbook: with
+// Enabled set, or if the project configuration says so (see
+// CoverConfig). Project and Context are the parts of the playlist
+// name (see ParseListName), shown on the cover unless configured
+// otherwise. Date is the build date shown, by default today.
+type CoverOptions struct {
+	Enabled bool
+	Project string
+	Context string
+	Date    time.Time
+}
+
+// CoverConfig holds the cover settings of a Project, so that all of
+// its songbooks look the same. They are part of the ProjectConfig:
+//
+//	cover:
+//	  enabled: true
+//	  title: The Keltners
+//	  logo: logo.png
+//	  dateFormat: 02.01.2006
+//
+// Enabled adds a cover to every songbook of the Project. Title and
+// Subtitle replace the Project and the Context on the cover. Logo is
+// an image file (PNG or JPEG), relative to the Project Folder.
+// DateFormat is a Go time layout, by default »2 January 2006«.
+type CoverConfig struct {
+	Enabled    bool   `yaml:"enabled"`
+	Title      string `yaml:"title"`
+	Subtitle   string `yaml:"subtitle"`
+	Logo       string `yaml:"logo"`
+	DateFormat string `yaml:"dateFormat"`
+}
+
+// coverPage lays out the cover of a songbook with n songs for the
+// Project with the Project Folder pdPath, as asked for by co and
+// configured by cc: title (Project), subtitle (Context) and logo in
+// the middle, date and number of songs at the bottom.
+func coverPage(co CoverOptions, cc CoverConfig, pdPath string,
+               n int) (pageContent, error) {
+	var pc pageContent
+	title, subtitle := cc.Title, cc.Subtitle
+	if title == "" {
+		title = strings.ReplaceAll(co.Project, "_", " ")
+	}
+	if subtitle == "" {
+		subtitle = strings.ReplaceAll(co.Context, "_", " ")
+	}
+	date := co.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+	layout := cc.DateFormat
+	if layout == "" {
+		layout = defaultDateFormat
+	}
+	width := pageWidth - 2 * pageMargin
+	y := pageHeight / 2
+	if cc.Logo != "" {
+		img, err := logoImage(filepath.Join(pdPath, cc.Logo))
+		if err != nil {
+			return pc, err
+		}
+		pc.Image = append(pc.Image, img)
+		y = img.Pos[1] - 2 * coverTitleSize
+	}
+	center := pageWidth / 2
+	titleSize := min(coverTitleSize, font.Size(title, textFont(title, boldFont), width))
+	pc.Text = append(pc.Text,
+		pageText{Value: title, Pos: [2]float64{center, y}, Align: "Center",
+		         Font: pageFont{boldFont, titleSize}})
+	if subtitle != "" {
+		y -= 2 * coverSubtitleSize
+		pc.Text = append(pc.Text,
+			pageText{Value: subtitle, Pos: [2]float64{center, y}, Align: "Center",
+			         Font: pageFont{defaultFont,
+			                        min(coverSubtitleSize,
+			                            font.Size(subtitle, textFont(subtitle, defaultFont),
+			                                      width))}})
+	}
+	songs := fmt.Sprintf("%d songs", n)
+	if n == 1 {
+		songs = "1 song"
+	}
+	for i, info := range []string{songs, date.Format(layout)} {
+		pc.Text = append(pc.Text,
+			pageText{Value: info,
+			         Pos: [2]float64{center, pageMargin + float64(i) * 1.5 * coverInfoSize},
+			         Align: "Center", Font: pageFont{defaultFont, coverInfoSize}})
+	}
+	fmt.Printf("Writing cover page: %s, %s\n", title, subtitle)
+	return pc, nil
+}
+
+// logoImage places the logo image at path in the upper half of the
+// cover, scaled to fit a square of coverLogoSize.
+func logoImage(path string) (pageImage, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return pageImage{}, fmt.Errorf("%w: logo: %w", ErrConfig, err)
+	}
+	defer fh.Close()
+	cfg, _, err := image.DecodeConfig(fh)
+	if err != nil {
+		return pageImage{}, fmt.Errorf("%w: logo %s: %w", ErrConfig, path, err)
+	}
+	scale := coverLogoSize / float64(max(cfg.Width, cfg.Height))
+	w, h := float64(cfg.Width) * scale, float64(cfg.Height) * scale
+	return pageImage{Src: path, Width: w, Height: h,
+	                 Pos: [2]float64{(pageWidth - w) / 2,
+	                                 pageHeight - 2 * pageMargin - h}}, nil
+}
@@ -0,0 +1,21 @@
+package songbook
+
+import(
+	"errors"
+)
+
+// Errors returned by this package wrap one of these, so callers can
+// tell with errors.Is what kind of problem stopped the songbook:
+// ErrListName for a playlist name without Project and Context (see
+// ParseListName), ErrPlaylist for a playlist that cannot be read or
+// has invalid entries, ErrFolder for a folder with PDF files that
+// cannot be read, ErrPDF for a PDF file that cannot be read or
+// written, and ErrConfig for broken configuration files (aliases,
+// transliterations, project configuration, library index).
+var (
+	ErrListName = errors.New("invalid playlist name")
+	ErrPlaylist = errors.New("cannot use playlist")
+	ErrFolder   = errors.New("cannot read folder")
+	ErrPDF      = errors.New("cannot process PDF")
+	ErrConfig   = errors.New("invalid configuration")
+)
@@ -0,0 +1,117 @@
+package songbook
+
+import(
+	"fmt"
+	"path/filepath"
+)
+
+// Folder is a folder with PDF files, i.e. a Project Folder or the
+// Cross-Project Folder, prepared for matching titles: the names of
+// its PDF files, its aliases, the library index with the match
+// keys and page data of the PDF files, and the candidates for
+// matching made from them.
+type Folder struct {
+	Path    string
+	Names   []string
+	Aliases *Aliases
+	Index   *Index
+	cands   []candidate
+}
+
+// OpenFolder reads the PDF filenames and the alias file of the
+// folder at path. With opts.Recursive set, the PDF files in all
+// subdirectories (except those matching opts.Exclude) are included,
+// named by their paths relative to the folder. Index entries for
+// PDF files that are gone from the folder are removed from ix. The
+// candidates for matching are made once here, with the PDF metadata
+// if opts.Metadata is set.
+func OpenFolder(path string, ix *Index, opts Options) (*Folder, error) {
+	aliases, err := ReadAliases(path)
+	if err != nil {
+		return nil, err
+	}
+	f := &Folder{Path: path, Aliases: aliases, Index: ix}
+	if opts.Recursive {
+		f.Names, err = GetAllPdNamesRecursive(path, opts.Exclude)
+	} else {
+		f.Names, err = GetAllPdNames(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	ix.Prune(path, f.Names, opts.Recursive)
+	f.cands = f.candidates(opts.Metadata)
+	return f, nil
+}
+
+// Match finds the PDF file(s) in the folder for a title. The
+// aliases of the folder are consulted first: an alias for a PDF
+// filename takes this file (see aliasFiles), an alias for a
+// canonical title is matched with that title instead. Then (or
+// without alias) the title is matched against the filenames like
+// with MatchTitle, in strict mode if opts.Strict is set, and also
+// against the metadata title and composer if the folder was opened
+// with opts.Metadata set (see OpenFolder). The Title of the Match
+// is the canonical title if the alias named one, so that the
+// folders of the following levels are searched for this title.
+func (f *Folder) Match(title string, opts Options) Match {
+	if target, ok := f.Aliases.Resolve(title); ok {
+		fmt.Printf("Alias %s in %s: %s\n", title, f.Path, target)
+		if isPdfName(target) {
+			if files := aliasFiles(target, f.Names); len(files) > 0 {
+				return Match{Names: files, Score: 1, Title: title}
+			}
+			return Match{Title: title}
+		}
+		title = target
+	}
+	m := matchCandidates(title, f.cands, opts.Strict)
+	m.Title = title
+	return m
+}
+
+// candidates returns the PDF files of the folder as candidates for
+// matching, with the match keys from the index. Only with metadata
+// set, the PDF files are read, and the labels from the PDF metadata
+// are included. For a file in a subdirectory, the filename label
+// includes the relative path, so »Yesterday (Ballads)« matches
+// »Ballads/Yesterday.pdf«.
+func (f *Folder) candidates(metadata bool) []candidate {
+	var cands []candidate
+	for _, fn := range f.Names {
+		fi := f.Index.Info(filepath.Join(f.Path, fn), metadata)
+		if fi == nil {
+			continue
+		}
+		labels := fi.Keys
+		if !metadata {
+			labels = labels[:1]
+		}
+		if Subfolder(fn) != "" {
+			labels = append([][]matchKey{matchKeys(stripPdfSuffix(fn))},
+			                labels[1:]...)
+		}
+		cands = append(cands, candidate{fn, labels})
+	}
+	return cands
+}
+
+// Subfolder returns the subdirectory part of a PDF filename
+// relative to its folder, or "" for a file right in the folder.
+func Subfolder(name string) string {
+	if d := filepath.Dir(name); d != "." {
+		return d
+	}
+	return ""
+}
+
+// Paths returns the paths of the PDF files names in the folder.
+// Files from subdirectories are reported on the way.
+func (f *Folder) Paths(names []string) []string {
+	for _, n := range names {
+		if sub := Subfolder(n); sub != "" {
+			fmt.Printf("   from subfolder %s: %s\n", sub, filepath.Base(n))
+		}
+	}
+	return filenamesToPaths(f.Path, names)
+}
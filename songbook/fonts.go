@@ -0,0 +1,145 @@
+package songbook
+
+import(
+	"fmt"
+	"sync"
+	"unicode"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/text/encoding/charmap"
+	"github.com/pdfcpu/pdfcpu/pkg/font"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+// TrueType fonts for text on generated pages that the PDF core
+// fonts (defaultFont, boldFont) cannot show, like Cyrillic or Greek
+// titles. The core fonts only know the Latin letters of the
+// Windows-1252 encoding. The Go fonts have Latin, Greek and
+// Cyrillic letters; they are installed as pdfcpu user fonts when
+// first needed.
+const (
+	unicodeFont     = "GoRegular" // Named like in the font file
+	unicodeBoldFont = "Go-Bold"
+)
+
+// unicodeFonts remembers whether the installation of the TrueType
+// fonts has been done, and its error.
+var unicodeFonts struct {
+	once sync.Once
+	err  error
+}
+
+// installUnicodeFonts installs the TrueType fonts into the font
+// folder of pdfcpu, unless they are there already, and loads them.
+func installUnicodeFonts() error {
+	unicodeFonts.once.Do(func() {
+		model.NewDefaultConfiguration() // Sets up font.UserFontDir
+		for name, ttf := range map[string][]byte{unicodeFont: goregular.TTF,
+		                                         unicodeBoldFont: gobold.TTF} {
+			if font.IsUserFont(name) {
+				continue
+			}
+			if err := font.InstallFontFromBytes(font.UserFontDir, name, ttf); err != nil {
+				unicodeFonts.err = err
+				return
+			}
+		}
+		unicodeFonts.err = font.LoadUserFonts()
+	})
+	return unicodeFonts.err
+}
+
+// coreFontText reports whether the PDF core fonts can show text.
+func coreFontText(text string) bool {
+	for _, r := range text {
+		if _, ok := charmap.Windows1252.EncodeRune(r); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// textFont returns the font to set text in on a generated page,
+// where the core font name (defaultFont or boldFont) is asked for:
+// name itself if it can show text, else the TrueType font of the
+// same weight. If that cannot be installed, it is name after all,
+// and the text shows gaps (see fontWarnings).
+func textFont(text, name string) string {
+	if coreFontText(text) {
+		return name
+	}
+	if err := installUnicodeFonts(); err != nil {
+		fmt.Printf("Cannot install font %s: %s\n", unicodeFont, err)
+		return name
+	}
+	if name == boldFont {
+		return unicodeBoldFont
+	}
+	return unicodeFont
+}
+
+// missingGlyphs returns the letters of text that the font chosen by
+// textFont (for defaultFont) has no glyphs for.
+func missingGlyphs(text string) []rune {
+	name := textFont(text, defaultFont)
+	if name == defaultFont {
+		var missing []rune
+		for _, r := range text {
+			if _, ok := charmap.Windows1252.EncodeRune(r); !ok {
+				missing = append(missing, r)
+			}
+		}
+		return missing
+	}
+	font.UserFontMetricsLock.RLock()
+	defer font.UserFontMetricsLock.RUnlock()
+	chars := font.UserFontMetrics[name].Chars
+	var missing []rune
+	for _, r := range text {
+		if _, ok := chars[uint32(r)]; !ok && !unicode.IsSpace(r) {
+			missing = append(missing, r)
+		}
+	}
+	return missing
+}
+
+// generatedTexts returns the texts that go onto the generated pages
+// of a songbook: the front matter, the song titles if they are in
+// the table of contents or stamped onto the pages, and the titles
+// of the divider pages.
+func generatedTexts(songs []Song, front []frontMatter, opts Options) []string {
+	var texts []string
+	for _, fm := range front {
+		for _, pc := range fm.pages {
+			for _, t := range pc.Text {
+				texts = append(texts, t.Value)
+			}
+		}
+	}
+	for _, s := range songs {
+		if s.Divider || opts.TOC || opts.Stamp.Titles {
+			texts = append(texts, s.Title)
+		}
+	}
+	return texts
+}
+
+// fontWarnings returns a warning for each of texts (titles etc. on
+// generated pages) that cannot be shown completely, as no font has
+// all of its letters.
+func fontWarnings(texts []string) []string {
+	var warnings []string
+	seen := map[string]bool{}
+	for _, t := range texts {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		if missing := missingGlyphs(t); len(missing) > 0 {
+			warnings = append(warnings,
+			                  fmt.Sprintf("No font for %s, left blank on generated pages: %s",
+			                              string(missing), t))
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,92 @@
+package songbook
+
+import(
+	"fmt"
+	"errors"
+	"strings"
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+// Imposition modes, see ImposeOptions.
+const (
+	ImposeBooklet = "booklet"
+	Impose2Up     = "2up"
+	Impose4Up     = "4up"
+)
+
+// ImposeOptions controls the print-ready copy of a songbook that is
+// written in addition to the songbook itself. Mode is one of
+// ImposeBooklet (two pages per side of a sheet, in the order for
+// saddle stitching: A4 sheets fold into an A5 booklet), Impose2Up or
+// Impose4Up (two or four pages per sheet in reading order); empty
+// means no print copy. Paper is the sheet size in pdfcpu's notation
+// (like "A4" or "LetterL"), by default A4 in the orientation that
+// fits. CropMarks adds lines to fold and cut along.
+type ImposeOptions struct {
+	Mode      string
+	Paper     string
+	CropMarks bool
+}
+
+// imposedPath returns the path of the print copy for the songbook
+// at outPath, like »CoolBand-gig-booklet.pdf«.
+func imposedPath(outPath, mode string) string {
+	return strings.TrimSuffix(outPath, ".pdf") + "-" + mode + ".pdf"
+}
+
+// Impose writes the print copy of the songbook at inPath, as
+// requested by imp, to outPath, without bookmarks.
+func Impose(inPath, outPath string, imp ImposeOptions) error {
+	paper := imp.Paper
+	var desc, marks string
+	var nup *model.NUp
+	var err error
+	switch imp.Mode {
+	case ImposeBooklet:
+		if paper == "" {
+			paper = "A4"
+		}
+		marks = "guides:off"
+		if imp.CropMarks {
+			marks = "guides:on"
+		}
+		desc = fmt.Sprintf("papersize:%s, %s", paper, marks)
+		nup, err = api.PDFBookletConfig(2, desc, nil)
+	case Impose2Up, Impose4Up:
+		n := 2
+		if imp.Mode == Impose4Up {
+			n = 4
+		}
+		if paper == "" {
+			paper = map[int]string{2: "A4L", 4: "A4P"}[n]
+		}
+		marks = "border:off"
+		if imp.CropMarks {
+			marks = "border:on"
+		}
+		desc = fmt.Sprintf("papersize:%s, margin:0, %s", paper, marks)
+		nup, err = api.PDFNUpConfig(n, desc, nil)
+	default:
+		return fmt.Errorf("%w: unknown imposition %q", ErrConfig, imp.Mode)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: imposition %s (%s): %w", ErrConfig, imp.Mode, desc, err)
+	}
+	fmt.Printf("Writing %s print copy to: %s\n", imp.Mode, outPath)
+	if imp.Mode == ImposeBooklet {
+		err = api.BookletFile([]string{inPath}, outPath, nil, nup, nil)
+	} else {
+		err = api.NUpFile([]string{inPath}, outPath, nil, nup, nil)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrPDF, outPath, err)
+	}
+	// The bookmarks of the songbook point to its own pages, which
+	// are not the pages of the print copy.
+	err = api.RemoveBookmarksFile(outPath, outPath, nil)
+	if err != nil && !errors.Is(err, api.ErrNoOutlines) {
+		return fmt.Errorf("%w: %s: %w", ErrPDF, outPath, err)
+	}
+	return nil
+}
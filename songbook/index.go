@@ -0,0 +1,243 @@
+package songbook
+
+import(
+	"fmt"
+	"os"
+	"time"
+	"maps"
+	"slices"
+	"strings"
+	"path/filepath"
+	"encoding/json"
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+// IndexFilename is the name of the library index file, kept in the
+// Base Path.
+const IndexFilename = ".songbook-index.json"
+
+// indexVersion changes whenever the meaning of the data in the
+// index changes, to make old index files be read from scratch.
+const indexVersion = 5
+
+// FileInfo is what the library index knows about one PDF file:
+// its size and modification time (to detect changes), its page
+// count and the size of each page in points, the document metadata
+// Title, Author (usually the composer) and Subject, and the match
+// keys derived from the filename and the metadata (see fileKeys).
+// Error is set if the file could not be read as PDF. Page data and
+// metadata are only there once the file has been read (see
+// Index.Info).
+type FileInfo struct {
+	Size      int64         `json:"size"`
+	ModTime   time.Time     `json:"mtime"`
+	PageCount int           `json:"pages"`
+	PageSizes [][2]float64  `json:"pageSizes,omitempty"`
+	Title     string        `json:"title,omitempty"`
+	Author    string        `json:"author,omitempty"`
+	Subject   string        `json:"subject,omitempty"`
+	Keys      [][]matchKey  `json:"keys,omitempty"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// Index is the library index: a FileInfo for each PDF file that has
+// been looked at, by its path. It is cached in a JSON file (one per
+// Base Path), so PDF files are read only if they are new or have
+// changed since the last run.
+type Index struct {
+	path    string
+	Version int                  `json:"version"`
+	KeysFor string               `json:"keysFor"` // See keysSignature
+	Files   map[string]*FileInfo `json:"files"`
+	changed bool
+}
+
+// OpenIndex reads the index cached in the file at path. A missing,
+// broken or outdated file gives an empty index. With an empty path
+// the index is not cached at all.
+func OpenIndex(path string) (*Index, error) {
+	ix := &Index{path: path, Version: indexVersion,
+	             KeysFor: keysSignature(), Files: map[string]*FileInfo{}}
+	if path == "" {
+		return ix, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return ix, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConfig, err)
+	}
+	var cached Index
+	if err := json.Unmarshal(data, &cached); err != nil {
+		fmt.Printf("Ignoring broken index %s: %s\n", path, err)
+		return ix, nil
+	}
+	if cached.Version != indexVersion || cached.Files == nil {
+		return ix, nil
+	}
+	ix.Files = cached.Files
+	if cached.KeysFor != ix.KeysFor {
+		// Transliterations have changed: the keys must be derived
+		// again, but the PDF files need not be read.
+		for p, fi := range ix.Files {
+			fi.Keys = fileKeys(filepath.Base(p), fi)
+		}
+		ix.changed = true
+	}
+	return ix, nil
+}
+
+// keysSignature describes the rules that match keys are derived
+// with, i.e. the Transliterations.
+func keysSignature() string {
+	var rules []string
+	for _, from := range slices.Sorted(maps.Keys(Transliterations)) {
+		rules = append(rules, from + "=" + Transliterations[from])
+	}
+	return strings.Join(rules, ",")
+}
+
+// Info returns the index entry for the PDF file at path, starting
+// a new one if the file is new or has changed since it was indexed.
+// With read set, the file is read for its page data and metadata
+// unless this has been done already; otherwise the entry may just
+// have the match keys of the filename, which is all that matching
+// titles against filenames needs. It returns nil if the file does
+// not exist (any more).
+func (ix *Index) Info(path string, read bool) *FileInfo {
+	st, err := os.Stat(path)
+	if err != nil {
+		fmt.Printf("Cannot index %s: %s\n", path, err)
+		return nil
+	}
+	fi := ix.Files[path]
+	if fi == nil || fi.Size != st.Size() || !fi.ModTime.Equal(st.ModTime()) {
+		fi = &FileInfo{Size: st.Size(), ModTime: st.ModTime()}
+		fi.Keys = fileKeys(filepath.Base(path), fi)
+		ix.Files[path] = fi
+		ix.changed = true
+	}
+	if read && !fi.read() {
+		fmt.Printf("Indexing %s\n", path)
+		if err := readPdfInfo(path, fi); err != nil {
+			fmt.Printf("Cannot read %s: %s\n", path, err)
+			fi.Error = err.Error()
+		}
+		fi.Keys = fileKeys(filepath.Base(path), fi)
+		ix.changed = true
+	}
+	return fi
+}
+
+// read reports whether the PDF file of fi has been read (see
+// Index.Info), successfully or not. A PDF file has at least one
+// page.
+func (fi *FileInfo) read() bool {
+	return fi.PageCount > 0 || fi.Error != ""
+}
+
+// PageCount returns the number of pages of the PDF file at path.
+func (ix *Index) PageCount(path string) (int, error) {
+	fi := ix.Info(path, true)
+	if fi == nil {
+		return 0, fmt.Errorf("%w: no PDF file %s", ErrPDF, path)
+	}
+	if fi.Error != "" {
+		return 0, fmt.Errorf("%w: %s: %s", ErrPDF, path, fi.Error)
+	}
+	return fi.PageCount, nil
+}
+
+// Prune removes the entries for PDF files in the folder dir that
+// are not among names (any more). names are relative to dir. With
+// recursive set, entries for files in subdirectories of dir are
+// pruned as well.
+func (ix *Index) Prune(dir string, names []string, recursive bool) {
+	for p := range ix.Files {
+		rel, err := filepath.Rel(dir, p)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue // Not in dir
+		}
+		if Subfolder(rel) != "" && !recursive {
+			continue
+		}
+		if !slices.Contains(names, rel) {
+			delete(ix.Files, p)
+			ix.changed = true
+		}
+	}
+}
+
+// Save writes the index back to its file if anything has changed.
+// The file is replaced as a whole, so readers never see half of it,
+// and Songbooks built at the same time (see RunBatch) do not write
+// into each other's copy.
+func (ix *Index) Save() error {
+	if ix.path == "" || !ix.changed {
+		return nil
+	}
+	data, err := json.MarshalIndent(ix, "", "  ")
+	if err != nil {
+		return err
+	}
+	fh, err := os.CreateTemp(filepath.Dir(ix.path),
+	                         filepath.Base(ix.path) + ".*.tmp")
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrConfig, err)
+	}
+	_, err = fh.Write(data)
+	if cerr := fh.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(fh.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(fh.Name(), ix.path)
+	}
+	if err != nil {
+		os.Remove(fh.Name())
+		return fmt.Errorf("%w: %w", ErrConfig, err)
+	}
+	ix.changed = false
+	return nil
+}
+
+// readPdfInfo fills in the page data and the metadata of fi from
+// the PDF file at path.
+func readPdfInfo(path string, fi *FileInfo) error {
+	fh, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+	ctx, err := api.ReadAndValidate(fh, model.NewDefaultConfiguration())
+	if err != nil {
+		return err
+	}
+	dims, err := ctx.PageDims()
+	if err != nil {
+		return err
+	}
+	fi.PageCount = ctx.PageCount
+	for _, d := range dims {
+		fi.PageSizes = append(fi.PageSizes, [2]float64{d.Width, d.Height})
+	}
+	fi.Title, fi.Author, fi.Subject = ctx.Title, ctx.Author, ctx.Subject
+	return nil
+}
+
+// fileKeys derives the match keys for the labels of a PDF file:
+// first its filename fn (without suffix), then, if the metadata has
+// a title, this title alone and together with the composer.
+func fileKeys(fn string, fi *FileInfo) [][]matchKey {
+	keys := [][]matchKey{matchKeys(stripPdfSuffix(fn))}
+	if fi.Title != "" {
+		keys = append(keys, matchKeys(fi.Title))
+		if fi.Author != "" {
+			keys = append(keys, matchKeys(fi.Title + " " + fi.Author))
+		}
+	}
+	return keys
+}
@@ -0,0 +1,249 @@
+package songbook
+
+import(
+	"fmt"
+	"io"
+	"os"
+	"time"
+	"slices"
+	"path/filepath"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// manifestVersion changes whenever the meaning of the data in the
+// manifest changes, to make songbooks with old manifests be built
+// again.
+const manifestVersion = 1
+
+// Manifest is what a songbook was built from, kept in a sidecar
+// file next to it (see manifestPath): the Playlist, the PDF files
+// (and images) that went into it, and Setup, a hash of everything
+// else that shapes it (the options, the order and pages of the
+// songs, the text of generated pages). Outputs are the files that
+// were written, the songbook and its print copy. If none of this has
+// changed, the songbook need not be built again.
+type Manifest struct {
+	Version  int            `json:"version"`
+	Setup    string         `json:"setup"`
+	Playlist *ManifestFile  `json:"playlist,omitempty"`
+	Inputs   []ManifestFile `json:"inputs"`
+	Outputs  []ManifestFile `json:"outputs"`
+}
+
+// ManifestFile is a file in the Manifest with its size and
+// modification time and, for inputs, the SHA-256 hash of its
+// content. The hash is only computed again if size or modification
+// time have changed, so files that were only touched (e.g. by a git
+// checkout) do not count as changed.
+type ManifestFile struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mtime"`
+	Hash    string    `json:"sha256,omitempty"`
+}
+
+// manifestPath returns the path of the manifest for the songbook at
+// outPath, a hidden file like ».CoolBand-gig.pdf.json«.
+func manifestPath(outPath string) string {
+	return filepath.Join(filepath.Dir(outPath),
+	                     "." + filepath.Base(outPath) + ".json")
+}
+
+// readManifest reads the manifest at path. A missing, broken or
+// outdated manifest gives nil, i.e. the songbook is built.
+func readManifest(path string) *Manifest {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil ||
+	   m.Version != manifestVersion {
+		return nil
+	}
+	return &m
+}
+
+// newManifest describes the songbook of res as it is about to be
+// built from the Playlist at listPath (none for an alphabetic
+// songbook) with opts and the front matter front. Hashes of
+// unchanged files are taken from the previous manifest old (if
+// any). The Outputs are left to addOutputs.
+func newManifest(res *Result, listPath string, opts Options,
+                 front []frontMatter, old *Manifest) (*Manifest, error) {
+	m := &Manifest{Version: manifestVersion}
+	var known []ManifestFile
+	if old != nil {
+		known = old.Inputs
+		if old.Playlist != nil {
+			known = append(known, *old.Playlist)
+		}
+	}
+	if listPath != "" {
+		f, err := manifestFile(listPath, known)
+		if err != nil {
+			return nil, err
+		}
+		m.Playlist = &f
+	}
+	var pages [][]pageContent
+	for _, fm := range front {
+		pages = append(pages, fm.pages)
+	}
+	var inputs []string
+	for _, s := range res.Songs {
+		inputs = append(inputs, s.Paths...)
+	}
+	for _, fm := range front {
+		for _, pc := range fm.pages {
+			for _, img := range pc.Image {
+				inputs = append(inputs, img.Src)
+			}
+		}
+	}
+	for _, p := range inputs {
+		f, err := manifestFile(p, known)
+		if err != nil {
+			return nil, err
+		}
+		m.Inputs = append(m.Inputs, f)
+	}
+	// Whether the songbook is built again or not does not shape it:
+	opts.DryRun, opts.Force = false, false
+	setup, err := json.Marshal(struct {
+		Options Options
+		Songs   []Song
+		Front   [][]pageContent
+	}{opts, res.Songs, pages})
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(setup)
+	m.Setup = hex.EncodeToString(sum[:])
+	return m, nil
+}
+
+// manifestFile describes the input file at path, taking the hash
+// from known if the file is listed there with the same size and
+// modification time.
+func manifestFile(path string, known []ManifestFile) (ManifestFile, error) {
+	st, err := os.Stat(path)
+	if err != nil {
+		return ManifestFile{}, fmt.Errorf("%w: %w", ErrPDF, err)
+	}
+	f := ManifestFile{Path: path, Size: st.Size(), ModTime: st.ModTime()}
+	i := slices.IndexFunc(known, func(k ManifestFile) bool {
+		return k.Path == path
+	})
+	if i >= 0 && known[i].Size == f.Size && known[i].ModTime.Equal(f.ModTime) {
+		f.Hash = known[i].Hash
+		return f, nil
+	}
+	if f.Hash, err = fileHash(path); err != nil {
+		return ManifestFile{}, fmt.Errorf("%w: %w", ErrPDF, err)
+	}
+	return f, nil
+}
+
+// fileHash returns the SHA-256 hash of the content of the file at
+// path, in hex.
+func fileHash(path string) (string, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer fh.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, fh); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// addOutputs records the files at paths as the Outputs of m.
+func (m *Manifest) addOutputs(paths ...string) error {
+	for _, p := range paths {
+		st, err := os.Stat(p)
+		if err != nil {
+			return err
+		}
+		m.Outputs = append(m.Outputs,
+		                   ManifestFile{Path: p, Size: st.Size(), ModTime: st.ModTime()})
+	}
+	return nil
+}
+
+// upToDate reports whether the songbook described by m is the one
+// already built as described by old: with the same setup, Playlist
+// and inputs, and all of its outputs still as they were written. The
+// outputs of old are taken over into m.
+func (m *Manifest) upToDate(old *Manifest, outputs ...string) bool {
+	if old == nil || old.Setup != m.Setup ||
+	   (old.Playlist == nil) != (m.Playlist == nil) ||
+	   (m.Playlist != nil && !sameFile(*old.Playlist, *m.Playlist)) ||
+	   !slices.EqualFunc(old.Inputs, m.Inputs, sameFile) {
+		return false
+	}
+	var paths []string
+	for _, f := range old.Outputs {
+		st, err := os.Stat(f.Path)
+		if err != nil || st.Size() != f.Size || !st.ModTime().Equal(f.ModTime) {
+			return false
+		}
+		paths = append(paths, f.Path)
+	}
+	if !slices.Equal(paths, outputs) {
+		return false
+	}
+	m.Outputs = old.Outputs
+	return true
+}
+
+// otherPlaylist returns the path of the Playlist the songbook of m
+// was built from, if it is not listPath (an empty path stands for an
+// alphabetic songbook), else "". Such Playlists take turns writing
+// one songbook, see ParseListName. m may be nil.
+func (m *Manifest) otherPlaylist(listPath string) string {
+	if m == nil {
+		return ""
+	}
+	other := ""
+	if m.Playlist != nil {
+		other = m.Playlist.Path
+	}
+	if other == listPath {
+		return ""
+	}
+	if other == "" {
+		return "(all PDF files)"
+	}
+	return other
+}
+
+// sameFile reports whether a and b are the same file with the same
+// content.
+func sameFile(a, b ManifestFile) bool {
+	return a.Path == b.Path && a.Hash == b.Hash
+}
+
+// save writes m to the file at path.
+func (m *Manifest) save(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// saveManifest saves the manifest m for the songbook res. Failing to
+// do so does not spoil the songbook (it is just built again next
+// time), so this only adds a warning to res.
+func saveManifest(m *Manifest, res *Result) {
+	if err := m.save(manifestPath(res.OutPath)); err != nil {
+		fmt.Printf("Cannot save manifest: %s\n", err)
+		res.Warnings = append(res.Warnings,
+		               fmt.Sprintf("Manifest not saved: %s", err))
+	}
+}
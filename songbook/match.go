@@ -0,0 +1,317 @@
+package songbook
+
+import(
+	"fmt"
+	"sort"
+	"slices"
+	"strings"
+	"regexp"
+)
+
+// Tuning of the fuzzy match. Scores range from 0 (nothing in
+// common) to 1 (the essence of the title is part of the essence
+// of the filename, as in the strict match).
+const (
+	matchThreshold   = 0.7  // Minimum score to accept a file
+	suggestThreshold = 0.5  // Minimum score to suggest a file
+	maxSuggestions   = 3
+	scoreEpsilon     = 1e-9 // Scores closer than this are a tie
+)
+
+// wordSepRE describes what separates words in titles and filenames
+// (apart from CamelCase, see camelRE). Combining marks belong to
+// the word, like the accent of »é« in a decomposed (NFD) filename.
+var wordSepRE = regexp.MustCompile(`[^\p{L}\p{M}\p{N}]+`)
+
+// Match is the result of matching a title against a list of PDF
+// filenames. Names holds the best matching filename(s); more than
+// one name means that several files matched equally well, i.e. the
+// title is ambiguous. If no file is good enough, Names is empty and
+// Suggestions may list close candidates.
+type Match struct {
+	Names       []string
+	Score       float64
+	Suggestions []string
+	Title       string // Title matched, after resolving an alias (see Folder.Match)
+}
+
+// Ambiguous reports whether more than one file matched equally well.
+func (m Match) Ambiguous() bool {
+	return len(m.Names) > 1
+}
+
+// candidate is a PDF file that a title is matched against, with
+// the labels that may match, prepared by matchKeys: the filename
+// (without suffix) and, if known, the title from the PDF metadata
+// etc.
+type candidate struct {
+	name   string
+	labels [][]matchKey
+}
+
+// matchKey is a string (a label or a title) prepared for matching
+// with one of the keyFuncs: the key of its essence as a whole, the
+// keys of its words, and the key of the essence of its first
+// hyphen-separated piece, i.e. the song title in a filename like
+// »Yesterday-Beatles-guitar«.
+type matchKey struct {
+	Compact string   `json:"c"`
+	Words   []string `json:"w,omitempty"`
+	Head    string   `json:"h,omitempty"`
+}
+
+// matchKeys prepares s for matching. It returns one matchKey for
+// each of the keyFuncs, in the same order.
+func matchKeys(s string) []matchKey {
+	var mks []matchKey
+	head, _, _ := strings.Cut(s, "-")
+	for _, key := range keyFuncs {
+		mks = append(mks, matchKey{key(essence(s)), words(s, key),
+		                           key(essence(head))})
+	}
+	return mks
+}
+
+// filenameCandidates returns the candidates for the PDF filenames
+// fns, labelled with just their filenames.
+func filenameCandidates(fns []string) []candidate {
+	var cands []candidate
+	for _, fn := range fns {
+		cands = append(cands,
+		               candidate{fn, [][]matchKey{matchKeys(stripPdfSuffix(fn))}})
+	}
+	return cands
+}
+
+// MatchTitle finds the PDF file(s) among the filenames fns for a
+// title (song). With strict set, it takes all files that pass the
+// substring test of fileMatch, like PdNamesForTitle. Otherwise each
+// file is scored by matchScore and the best one is taken, or all of
+// the best ones if there is a tie. A tie is broken in favour of the
+// files whose song title (see matchKey) the title covers most, so
+// »Let It Be« takes »LetItBe.pdf« rather than »LetItBeMe.pdf«, but
+// stays a tie for »Summertime-Holiday.pdf« and
+// »Summertime-BigBrother.pdf«. Files that score below the
+// threshold are not taken, but the closest ones are returned as
+// suggestions.
+func MatchTitle(title string, fns []string, strict bool) Match {
+	return matchCandidates(title, filenameCandidates(fns), strict)
+}
+
+// matchCandidates does the work of MatchTitle for candidates with
+// any number of labels. A candidate counts with its best label.
+func matchCandidates(title string, cands []candidate, strict bool) Match {
+	var m Match
+	tks := matchKeys(title)
+	if strict {
+		for _, c := range cands {
+			if slices.ContainsFunc(c.labels, func(lks []matchKey) bool {
+				return keysContain(lks, tks)
+			}) {
+				m.Names = append(m.Names, c.name)
+			}
+		}
+		m.Score = 1
+		return m
+	}
+	type scored struct {
+		name  string
+		score float64
+		cover float64 // Tie-breaker, see keysCover
+	}
+	var all []scored
+	for _, c := range cands {
+		var best, cover float64
+		for _, lks := range c.labels {
+			score := keysScore(lks, tks)
+			if score - best > scoreEpsilon {
+				best, cover = score, keysCover(lks, tks)
+			} else if best - score <= scoreEpsilon {
+				cover = max(cover, keysCover(lks, tks))
+			}
+		}
+		all = append(all, scored{c.name, best, cover})
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		if d := all[i].score - all[j].score; d > scoreEpsilon || d < -scoreEpsilon {
+			return d > 0
+		}
+		return all[i].cover - all[j].cover > scoreEpsilon
+	})
+	if len(all) == 0 {
+		return m
+	}
+	if all[0].score < matchThreshold {
+		for _, s := range all {
+			if s.score < suggestThreshold || len(m.Suggestions) == maxSuggestions {
+				break
+			}
+			m.Suggestions = append(m.Suggestions, s.name)
+		}
+		return m
+	}
+	m.Score = all[0].score
+	for _, s := range all {
+		if m.Score - s.score > scoreEpsilon || all[0].cover - s.cover > scoreEpsilon {
+			break
+		}
+		m.Names = append(m.Names, s.name)
+	}
+	return m
+}
+
+// keysContain is the substring test of fileMatch for prepared
+// strings: it reports whether the label with the match keys lks
+// contains the title with the match keys tks.
+func keysContain(lks, tks []matchKey) bool {
+	for i := range tks {
+		if tks[i].Compact != "" &&
+		   strings.Contains(lks[i].Compact, tks[i].Compact) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchScore rates how well a label (a PDF filename without suffix,
+// a title from PDF metadata) matches a title, from 0 to 1.
+// It takes the better of two views:
+// The compact view compares the essences of both strings and
+// allows for typos: it is the share of the title's essence that is
+// found in the filename's essence with the fewest edits.
+// The word view tolerates a different word order: each word of the
+// title is compared with its most similar word in the filename,
+// and the similarities are averaged.
+// Words of the filename that are not in the title (like the artist)
+// do not lower the score; words of the title that are not in the
+// filename do, so »Yesterday (Beatles)« does not match
+// »Yesterday.pdf«.
+// Like fileMatch, the score is computed with each of the keyFuncs
+// and the best one counts.
+func matchScore(label, title string) float64 {
+	return keysScore(matchKeys(label), matchKeys(title))
+}
+
+// keysScore computes matchScore for prepared strings, from the
+// match keys of the label, lks, and of the title, tks.
+func keysScore(lks, tks []matchKey) float64 {
+	var best float64
+	for i := range tks {
+		best = max(best, keyedScore(lks[i], tks[i]))
+	}
+	return best
+}
+
+// keysCover rates how much of the song title of a label (the Head
+// of its match keys lks) the title with the match keys tks covers,
+// from 0 to 1. It breaks ties of keysScore.
+func keysCover(lks, tks []matchKey) float64 {
+	var best float64
+	for i := range tks {
+		if n := len([]rune(lks[i].Head)); n > 0 {
+			best = max(best, min(1, float64(len([]rune(tks[i].Compact))) / float64(n)))
+		}
+	}
+	return best
+}
+
+// keyedScore computes the score described for matchScore with the
+// match keys l (label) and t (title) for one of the keyFuncs.
+func keyedScore(l, t matchKey) float64 {
+	if t.Compact == "" {
+		return 0
+	}
+	compact := 1 - float64(substringDistance(t.Compact, l.Compact)) /
+	               float64(len([]rune(t.Compact)))
+	var sum float64
+	for _, tw := range t.Words {
+		var best float64
+		for _, lw := range l.Words {
+			best = max(best, similarity(tw, lw))
+		}
+		sum += best
+	}
+	word := sum / float64(len(t.Words))
+	return max(compact, word)
+}
+
+// words splits a title or filename into its words, reduced to
+// their essence and turned into match keys by key. CamelCase counts
+// as a word boundary.
+func words(s string, key func(string) string) []string {
+	var ws []string
+	s = camelRE.ReplaceAllString(s, "$1 $2")
+	for _, w := range wordSepRE.Split(s, -1) {
+		if e := essence(w); e != "" {
+			ws = append(ws, key(e))
+		}
+	}
+	return ws
+}
+
+// similarity rates the similarity of two words from 0 to 1 based
+// on their edit distance.
+func similarity(a, b string) float64 {
+	n := max(len([]rune(a)), len([]rune(b)))
+	if n == 0 {
+		return 1
+	}
+	return 1 - float64(editDistance(a, b)) / float64(n)
+}
+
+// editDistance returns the Levenshtein distance of a and b, i.e.
+// the number of rune insertions, deletions and substitutions that
+// turn a into b.
+func editDistance(a, b string) int {
+	return distance([]rune(a), []rune(b), false)
+}
+
+// substringDistance returns the smallest edit distance between
+// needle and any substring of haystack; 0 means that haystack
+// contains needle.
+func substringDistance(needle, haystack string) int {
+	return distance([]rune(needle), []rune(haystack), true)
+}
+
+// distance computes the edit distance of a and b row by row. With
+// anywhere set, a may start and end at any position of b without
+// cost (approximate substring search).
+func distance(a, b []rune, anywhere bool) int {
+	prev := make([]int, len(b) + 1)
+	cur := make([]int, len(b) + 1)
+	for j := range prev {
+		if !anywhere {
+			prev[j] = j
+		}
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j] + 1, cur[j-1] + 1, prev[j-1] + cost)
+		}
+		prev, cur = cur, prev
+	}
+	if !anywhere {
+		return prev[len(b)]
+	}
+	return slices.Min(prev)
+}
+
+// ambiguityMessage describes a match of several files for one title.
+func ambiguityMessage(title string, m Match) string {
+	return fmt.Sprintf("Ambiguous title %s, taking all of: %s",
+	                   title, strings.Join(m.Names, ", "))
+}
+
+// stripPdfSuffix removes the ».pdf« suffix from a filename. Other
+// dots are kept, unlike with filepath.Ext.
+func stripPdfSuffix(fn string) string {
+	if isPdfName(fn) {
+		return fn[:len(fn) - len(".pdf")]
+	}
+	return fn
+}
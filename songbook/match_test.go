@@ -0,0 +1,121 @@
+package songbook
+
+import(
+	"slices"
+	"testing"
+)
+
+// »Café« decomposed (NFD), as in filenames on macOS, and composed
+// (NFC).
+const (
+	cafeNFD = "Cafe\u0301"
+	cafeNFC = "Caf\u00e9"
+)
+
+func TestEssence(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"Autumn Leaves", "autumnleaves"},
+		{"Brown-Eyed_Girl!", "browneyedgirl"},
+		{"Калинка", "калинка"},
+		{"КАЛИНКА (live)", "калинкаlive"},
+		{"Ελλάδα", "ελλάδα"},
+		{"かっこう", "かっこう"},
+		{"???", ""},
+		{"", ""},
+		{cafeNFD, "café"},
+		{cafeNFC, "café"},
+	}
+	for _, tt := range tests {
+		if got := essence(tt.s); got != tt.want {
+			t.Errorf("essence(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestFileMatch(t *testing.T) {
+	tests := []struct {
+		filename string
+		title    string
+		want     bool
+	}{
+		{"AutumnLeaves.pdf", "Autumn Leaves", true},
+		{"UeberDenWolken.pdf", "Über den Wolken", true},
+		{"Uber-den-Wolken.pdf", "Über den Wolken", true},
+		{"Калинка.pdf", "калинка", true},
+		{"Калинка.pdf", "Калина", false},
+		{"Ελλάδα.pdf", "ΕΛΛΆΔΑ", true},
+		{"Ελλάδα-Πάρος.pdf", "Ελλάδα", true},
+		{"かっこう.pdf", "かっこう", true},
+		{"かっこう.pdf", "がっこう", false},
+		{"がっこう.pdf", "かっこう", false},
+		{"AutumnLeaves.pdf", "???", false},
+		{"???.pdf", "???", false},
+		{"Cafe.pdf", cafeNFC, true},
+		{cafeNFD + ".pdf", cafeNFC, true},
+		{cafeNFC + ".pdf", cafeNFD, true},
+		{cafeNFD + "DelMar.pdf", cafeNFC + " del Mar", true},
+	}
+	for _, tt := range tests {
+		if got := fileMatch(tt.filename, tt.title); got != tt.want {
+			t.Errorf("fileMatch(%q, %q) = %v, want %v",
+			         tt.filename, tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestMatchTitle(t *testing.T) {
+	fns := []string{
+		"AutumnLeaves.pdf",
+		"LetItBe.pdf",
+		"LetItBeMe.pdf",
+		"Summertime-BigBrother.pdf",
+		"Summertime-Holiday.pdf",
+		"Калинка.pdf",
+		"Ελλάδα.pdf",
+		"かっこう.pdf",
+		cafeNFD + "DelMar.pdf",
+	}
+	tests := []struct {
+		title  string
+		strict bool
+		want   []string
+	}{
+		{"Autumn Leaves", false, []string{"AutumnLeaves.pdf"}},
+		{"Leaves Autumn", false, []string{"AutumnLeaves.pdf"}},
+		{"Autum Leafs", false, []string{"AutumnLeaves.pdf"}},
+		{"Let It Be", false, []string{"LetItBe.pdf"}},
+		{"Let It Be Me", false, []string{"LetItBeMe.pdf"}},
+		{"Summertime", false,
+		 []string{"Summertime-BigBrother.pdf", "Summertime-Holiday.pdf"}},
+		{"Калинка", false, []string{"Калинка.pdf"}},
+		{"КАЛИНКА", true, []string{"Калинка.pdf"}},
+		{"Ελλάδα", false, []string{"Ελλάδα.pdf"}},
+		{"ελλάδα", true, []string{"Ελλάδα.pdf"}},
+		{"かっこう", false, []string{"かっこう.pdf"}},
+		{"がっこう", true, nil},
+		{"???", false, nil},
+		{"???", true, nil},
+		{cafeNFC + " del Mar", false, []string{cafeNFD + "DelMar.pdf"}},
+		{cafeNFC + " del Mar", true, []string{cafeNFD + "DelMar.pdf"}},
+		{"Cafe del Mar", true, []string{cafeNFD + "DelMar.pdf"}},
+		{"Yesterday", false, nil},
+	}
+	for _, tt := range tests {
+		m := MatchTitle(tt.title, fns, tt.strict)
+		if !slices.Equal(m.Names, tt.want) {
+			t.Errorf("MatchTitle(%q, strict %v) = %q, want %q",
+			         tt.title, tt.strict, m.Names, tt.want)
+		}
+	}
+}
+
+func TestMatchTitlePunctuation(t *testing.T) {
+	m := MatchTitle("???", []string{"AutumnLeaves.pdf", "???.pdf"}, false)
+	if len(m.Names) > 0 || len(m.Suggestions) > 0 {
+		t.Errorf("MatchTitle(%q) = %q, suggestions %q, want nothing",
+		         "???", m.Names, m.Suggestions)
+	}
+}
@@ -0,0 +1,141 @@
+package songbook
+
+import(
+	"fmt"
+	"bytes"
+	"encoding/json"
+	"os"
+	"strconv"
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+// Generated pages (table of contents etc.) are A4 portrait.
+// Coordinates are in points with the origin in the lower left
+// corner, as used by pdfcpu.
+const (
+	pageWidth   = 595.0
+	pageHeight  = 842.0
+	pageMargin  = 60.0
+	defaultFont = "Helvetica"
+	boldFont    = "Helvetica-Bold"
+)
+
+// pageText is one piece of text on a generated page. It is
+// marshalled into the JSON that pdfcpu's create command reads.
+// Either Pos or Anchor (like "center") places the text.
+type pageText struct {
+	Value  string     `json:"value"`
+	Pos    [2]float64 `json:"pos"`
+	Anchor string     `json:"anchor,omitempty"`
+	Align  string     `json:"align,omitempty"`
+	Font   pageFont   `json:"font"`
+}
+
+type pageFont struct {
+	Name string `json:"name"`
+	Size int    `json:"size"`
+}
+
+// pageImage is an image on a generated page, placed at Pos (its
+// lower left corner) and scaled to Width and Height.
+type pageImage struct {
+	Src    string     `json:"src"`
+	Pos    [2]float64 `json:"pos"`
+	Width  float64    `json:"width"`
+	Height float64    `json:"height"`
+}
+
+// pageContent is what is on one generated page.
+type pageContent struct {
+	Text  []pageText  `json:"text"`
+	Image []pageImage `json:"image,omitempty"`
+}
+
+type pageSpec struct {
+	Content pageContent `json:"content"`
+}
+
+type pagesDoc struct {
+	Paper  string              `json:"paper"`
+	Origin string              `json:"origin"`
+	Pages  map[string]pageSpec `json:"pages"`
+}
+
+// createPages writes a new PDF file to path that has one page for
+// each element of pages, showing the texts of that element.
+func createPages(pages [][]pageText, path string) error {
+	return writePages(textPages(pages), path)
+}
+
+// textPages turns pages with just texts into pageContent.
+func textPages(pages [][]pageText) []pageContent {
+	var pcs []pageContent
+	for _, texts := range pages {
+		pcs = append(pcs, pageContent{Text: texts})
+	}
+	return pcs
+}
+
+// writePages is createPages for pages with any content. Texts that
+// the core fonts cannot show are set in a TrueType font, see
+// textFont.
+func writePages(pages []pageContent, path string) error {
+	doc := pagesDoc{Paper: "A4", Origin: "LowerLeft",
+	                Pages: map[string]pageSpec{}}
+	for i, pc := range pages {
+		texts := make([]pageText, len(pc.Text))
+		for j, t := range pc.Text {
+			t.Font.Name = textFont(t.Value, t.Font.Name)
+			texts[j] = t
+		}
+		pc.Text = texts
+		doc.Pages[strconv.Itoa(i + 1)] = pageSpec{pc}
+	}
+	js, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := api.Create(nil, bytes.NewReader(js), &buf, nil); err != nil {
+		return fmt.Errorf("%w: %w", ErrPDF, err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("%w: %w", ErrPDF, err)
+	}
+	return nil
+}
+
+// tempPdfPath returns the path of a new, empty temporary file for
+// an intermediate PDF. The caller removes it when done.
+func tempPdfPath(pattern string) (string, error) {
+	fh, err := os.CreateTemp("", pattern + "-*.pdf")
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrPDF, err)
+	}
+	fh.Close()
+	return fh.Name(), nil
+}
+
+// insertPadPages inserts the blank pages planned by numberPages
+// (see Song.PadBefore) into the merged songbook at path. A blank
+// page gets the size of the first page of the song it precedes.
+func insertPadPages(path string, songs []Song) error {
+	var sel []string
+	var pads int
+	for _, s := range songs {
+		if s.PadBefore == 0 {
+			continue
+		}
+		pads += s.PadBefore
+		// Page in the songbook as merged, without the pads:
+		sel = append(sel, strconv.Itoa(s.StartPage - pads))
+	}
+	if len(sel) == 0 {
+		return nil
+	}
+	fmt.Printf("Inserting %d blank page(s)\n", pads)
+	if err := api.InsertPagesFile(path, path, sel, true, nil, nil); err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrPDF, path, err)
+	}
+	return nil
+}
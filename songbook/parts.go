@@ -0,0 +1,146 @@
+package songbook
+
+import(
+	"slices"
+	"strings"
+	"path/filepath"
+)
+
+// DefaultFallbackParts are the versions of a song that are taken
+// for an instrument part the song has no file for, in this order.
+var DefaultFallbackParts = []string{"full", "lead"}
+
+// PartsConfig holds the instrument parts of a Project, as part of
+// the ProjectConfig:
+//
+//	parts:
+//	  names: [guitar, bass, keys, drums]
+//	  fallback: [full, lead]
+//
+// Names are the parts the Project has files for. Fallback replaces
+// DefaultFallbackParts.
+type PartsConfig struct {
+	Names    []string `yaml:"names"`
+	Fallback []string `yaml:"fallback"`
+}
+
+// partSelector chooses the files for one instrument part among the
+// PDF files that match a title. Part files are named with the part
+// as the last hyphen-separated piece, like »TheBoxer-bass.pdf«.
+type partSelector struct {
+	part     string
+	fallback []string
+	known    map[string]bool // Essences of all part names
+}
+
+// newPartSelector prepares the choice of files for the part
+// opts.Part. The fallback parts come from opts.Fallback if set, else
+// from the project configuration pc, else DefaultFallbackParts.
+// Besides these, the parts of opts.Parts and pc.Names are known as
+// parts, so their files are not taken as general versions. It
+// returns nil for an empty part, meaning that all matching files are
+// taken.
+func newPartSelector(opts Options, pc PartsConfig) *partSelector {
+	part, fallback := opts.Part, opts.Fallback
+	if part == "" {
+		return nil
+	}
+	if len(fallback) == 0 {
+		fallback = pc.Fallback
+	}
+	if len(fallback) == 0 {
+		fallback = DefaultFallbackParts
+	}
+	ps := &partSelector{part: part, fallback: fallback, known: map[string]bool{}}
+	known := append(append([]string{part}, fallback...), opts.Parts...)
+	for _, p := range append(known, pc.Names...) {
+		ps.known[essence(p)] = true
+	}
+	return ps
+}
+
+// split separates the part from the name of a PDF file: it returns
+// the name without the part and without suffix, and the part (its
+// essence), or "" if the file is not a part file.
+func (ps *partSelector) split(name string) (string, string) {
+	stem := stripPdfSuffix(name)
+	i := strings.LastIndex(stem, "-")
+	if i < 0 {
+		return stem, ""
+	}
+	if p := essence(stem[i+1:]); ps.known[p] {
+		return stem[:i], p
+	}
+	return stem, ""
+}
+
+// choose returns the files among names (the files matching one
+// title) to take for the part: the files of the part itself, else
+// those of the first fallback part there are files for, else the
+// files that are not part files at all. It also returns the part
+// taken, "" for files that are not part files, or nothing if there
+// is no file to take.
+func (ps *partSelector) choose(names []string) ([]string, string) {
+	byPart := map[string][]string{}
+	for _, n := range names {
+		_, p := ps.split(filepath.Base(n))
+		byPart[p] = append(byPart[p], n)
+	}
+	for _, p := range append([]string{ps.part}, ps.fallback...) {
+		if files := byPart[essence(p)]; len(files) > 0 {
+			return files, p
+		}
+	}
+	return byPart[""], ""
+}
+
+// parts returns the parts that there are files for among names,
+// sorted by alphabet, for messages about a missing part.
+func (ps *partSelector) parts(names []string) []string {
+	var parts []string
+	for _, n := range names {
+		if _, p := ps.split(filepath.Base(n)); p != "" && !slices.Contains(parts, p) {
+			parts = append(parts, p)
+		}
+	}
+	slices.Sort(parts)
+	return parts
+}
+
+// filter returns the files among names to take for the part, for
+// a folder with the files of many songs: the files are grouped by
+// song (i.e. by their names without the part), and each group is
+// chosen from like with choose.
+func (ps *partSelector) filter(names []string) []string {
+	groups := map[string][]string{}
+	var songs []string
+	for _, n := range names {
+		stem, _ := ps.split(filepath.Base(n))
+		key := filepath.Join(filepath.Dir(n), stem)
+		if groups[key] == nil {
+			songs = append(songs, key)
+		}
+		groups[key] = append(groups[key], n)
+	}
+	var chosen []string
+	for _, key := range songs {
+		files, _ := ps.choose(groups[key])
+		chosen = append(chosen, files...)
+	}
+	return chosen
+}
+
+// samePart reports whether a and b name the same part, like
+// »Guitar« and »guitar«.
+func samePart(a, b string) bool {
+	return essence(a) == essence(b)
+}
+
+// versionName names the version of a song that choose took, for
+// messages.
+func versionName(part string) string {
+	if part == "" {
+		return "general"
+	}
+	return part
+}
@@ -0,0 +1,176 @@
+package songbook
+
+import(
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"path/filepath"
+	"encoding/json"
+	"gopkg.in/yaml.v2"
+)
+
+// pagesSuffixRE finds a page selection at the end of a playlist
+// entry, like »Autumn Leaves [3]« or »Medley [2-4, 6]«.
+var pagesSuffixRE = regexp.MustCompile(`\s*\[\s*(\d[\d\s,-]*)\]\s*$`)
+
+// headingRE matches a heading line in a text playlist, like
+// »## Set 2«, and finds the text of the heading.
+var headingRE = regexp.MustCompile(`\A\s*##+\s*(\S.*?)\s*\z`)
+
+// Entry is one entry of a playlist. In a text playlist only the
+// Title is set, and the Pages if the title ends with a page
+// selection in brackets (see splitPages), or the Section for a
+// heading line. Structured playlists (YAML or JSON) may add:
+// File, the name of a PDF file to use instead of searching one by
+// title; Pages, a page selection like "2-3" or "1,4" to take only
+// these pages from the PDF file(s); Project, the name of another
+// Project Folder (under the same Base Path) to look in first;
+// Notes, free-form remarks about the song; Key and Tempo, shown on
+// the set card (see SetCardOptions).
+// An entry with a Section is no song, but starts a new section of
+// the songbook (like »Set 2« or »Encores«) with a divider page.
+type Entry struct {
+	Section string `json:"section" yaml:"section"`
+	Title   string `json:"title"   yaml:"title"`
+	File    string `json:"file"    yaml:"file"`
+	Pages   string `json:"pages"   yaml:"pages"`
+	Project string `json:"project" yaml:"project"`
+	Notes   string `json:"notes"   yaml:"notes"`
+	Key     string `json:"key"     yaml:"key"`
+	Tempo   string `json:"tempo"   yaml:"tempo"`
+}
+
+// UnmarshalJSON lets a JSON playlist give an entry as a plain
+// string (the title) instead of an object, and the pages as a
+// number (like »"pages": 2«) as well as a string, like YAML does.
+func (e *Entry) UnmarshalJSON(data []byte) error {
+	var title string
+	if err := json.Unmarshal(data, &title); err == nil {
+		*e = Entry{Title: title}
+		return nil
+	}
+	type plain Entry // Without this method, to avoid recursion
+	var entry struct {
+		plain
+		Pages json.RawMessage `json:"pages"`
+	}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return err
+	}
+	*e = Entry(entry.plain)
+	if len(entry.Pages) == 0 || string(entry.Pages) == "null" {
+		return nil
+	}
+	if json.Unmarshal(entry.Pages, &e.Pages) == nil {
+		return nil
+	}
+	var n json.Number
+	if json.Unmarshal(entry.Pages, &n) == nil {
+		e.Pages = n.String()
+		return nil
+	}
+	return fmt.Errorf("invalid pages %s, want a number or a string like \"2-3\"",
+	                  entry.Pages)
+}
+
+// UnmarshalYAML lets a YAML playlist give an entry as a plain
+// string (the title) instead of a mapping.
+func (e *Entry) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var title string
+	if err := unmarshal(&title); err == nil {
+		*e = Entry{Title: title}
+		return nil
+	}
+	type plain Entry // Without this method, to avoid recursion
+	return unmarshal((*plain)(e))
+}
+
+// ReadEntries reads the playlist at the given path and returns its
+// entries. The format is chosen by the filename suffix: ».yaml« or
+// ».yml« for YAML, ».json« for JSON, and anything else for a text
+// playlist as read by ReadPlaylist.
+// A structured playlist is a list of entries, each being either a
+// title or a mapping with the fields described for Entry:
+//
+//	- Autumn Leaves
+//	- title: Summertime
+//	  file: Summertime-Holiday.pdf
+//	  pages: 2-3
+//	  notes: Intro only piano
+//	- section: Encores
+//
+// In any format, a title may end with a page selection in brackets
+// instead of the pages field, like »Autumn Leaves [3]«.
+func ReadEntries(path string) ([]Entry, error) {
+	var entries []Entry
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrPlaylist, err)
+		}
+		if err := yaml.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrPlaylist, path, err)
+		}
+	case ".json":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrPlaylist, err)
+		}
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrPlaylist, path, err)
+		}
+	default:
+		titles, err := ReadPlaylist(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range titles {
+			if m := headingRE.FindStringSubmatch(t); m != nil {
+				entries = append(entries, Entry{Section: m[1]})
+			} else {
+				entries = append(entries, Entry{Title: t})
+			}
+		}
+	}
+	for i, e := range entries {
+		if e.Pages == "" {
+			entries[i].Title, entries[i].Pages = splitPages(e.Title)
+		}
+	}
+	return entries, nil
+}
+
+// splitPages separates a page selection in brackets at the end of
+// a playlist entry from the title: »Medley [2-4]« gives »Medley«
+// and »2-4«. Only digits, commas and hyphens count as a selection,
+// so a title like »Hey Jude [Live]« stays as it is.
+func splitPages(title string) (string, string) {
+	m := pagesSuffixRE.FindStringSubmatchIndex(title)
+	if m == nil {
+		return title, ""
+	}
+	sel := strings.Join(strings.Fields(title[m[2]:m[3]]), "")
+	return title[:m[0]], strings.Trim(sel, ",")
+}
+
+// pinnedPath returns the path of the PDF file fn that a playlist
+// entry names explicitly. A relative fn is looked up in each of the
+// folders dirs in turn. An empty string means the file was not
+// found.
+func pinnedPath(fn string, dirs ...string) string {
+	if filepath.IsAbs(fn) {
+		if _, err := os.Stat(fn); err == nil {
+			return fn
+		}
+		return ""
+	}
+	for _, d := range dirs {
+		p := filepath.Join(d, fn)
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return ""
+}
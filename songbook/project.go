@@ -0,0 +1,82 @@
+package songbook
+
+import(
+	"fmt"
+	"os"
+	"path/filepath"
+	"gopkg.in/yaml.v2"
+)
+
+// ProjectConfigFilename is the name of the optional configuration
+// file in a Project Folder.
+const ProjectConfigFilename = "songbook.yaml"
+
+// ProjectConfig holds the settings of a Project, read from the file
+// songbook.yaml in its Project Folder:
+//
+//	search:
+//	  - OtherBand
+//	  - Original
+//	  - /home/me/library
+//	cover:
+//	  enabled: true
+//	  logo: logo.png
+//	parts:
+//	  names: [guitar, bass, keys]
+//
+// Search lists the folders to look for sheet music in after the
+// Project Folder itself, see SearchChain. Cover holds the settings
+// of the cover page, see CoverConfig, and Parts the instrument
+// parts, see PartsConfig.
+type ProjectConfig struct {
+	Search []string    `yaml:"search"`
+	Cover  CoverConfig `yaml:"cover"`
+	Parts  PartsConfig `yaml:"parts"`
+}
+
+// ReadProjectConfig reads the configuration of the Project with the
+// Project Folder pdPath. Without a configuration file, it returns
+// the zero value.
+func ReadProjectConfig(pdPath string) (ProjectConfig, error) {
+	var pc ProjectConfig
+	path := filepath.Join(pdPath, ProjectConfigFilename)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return pc, nil
+	} else if err != nil {
+		return pc, fmt.Errorf("%w: %w", ErrConfig, err)
+	}
+	if err := yaml.Unmarshal(data, &pc); err != nil {
+		return pc, fmt.Errorf("%w: %s: %w", ErrConfig, path, err)
+	}
+	return pc, nil
+}
+
+// SearchChain returns the folders to look for the sheet music of a
+// title in, in this order. The first one is always the Project
+// Folder pdPath. The others are taken from opts.Search if set, else
+// from configured, the search list in the project configuration
+// (see ProjectConfig), else it is just the folder of generic PDF
+// files, genPdPath. Relative folder names are relative to the Base
+// Path, i.e. the parent of pdPath, so sibling Projects are given by
+// their names.
+func SearchChain(pdPath, genPdPath string, opts Options,
+                 configured []string) []string {
+	search := opts.Search
+	if len(search) == 0 {
+		search = configured
+	}
+	if len(search) == 0 {
+		return []string{pdPath, genPdPath}
+	}
+	chain := []string{pdPath}
+	for _, s := range search {
+		if !filepath.IsAbs(s) {
+			s = filepath.Join(filepath.Dir(pdPath), s)
+		}
+		if filepath.Clean(s) != filepath.Clean(pdPath) {
+			chain = append(chain, s)
+		}
+	}
+	return chain
+}
@@ -0,0 +1,120 @@
+package songbook
+
+import(
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"path/filepath"
+	"encoding/json"
+	"text/tabwriter"
+)
+
+// WriteReport writes a report of the songbook res to w: as JSON
+// (the Result with all its fields) if asJSON is set, else as text
+// with one line per song, telling its pages in the songbook (and
+// whether a blank page was inserted before it), the level of the
+// SearchChain and the file(s) it came from, followed by the missing
+// titles and the warnings. Dividers are shown as headings between
+// the songs.
+func WriteReport(w io.Writer, res *Result, asJSON bool) error {
+	if asJSON {
+		r := *res // Empty lists rather than null, for scripts
+		r.Songs = append([]Song{}, r.Songs...)
+		r.Missing = append([]MissingTitle{}, r.Missing...)
+		r.Warnings = append([]string{}, r.Warnings...)
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	}
+	head := "Songbook"
+	if res.Part != "" {
+		head += " for " + res.Part
+	}
+	if res.DryRun {
+		head += " (dry run)"
+	} else if res.UpToDate {
+		head += " (up to date)"
+	}
+	pads := ""
+	if res.PadPages > 0 {
+		pads = fmt.Sprintf(" (%d blank for spreads)", res.PadPages)
+	}
+	fmt.Fprintf(w, "%s %s: %d page(s)%s, %d song(s), %d missing\n\n",
+	            head, res.OutPath, res.Pages, pads, songCount(res.Songs),
+	            len(res.Missing))
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tPages\tLevel\tTitle\tFile(s)")
+	var n int // Number of the song, not counting dividers
+	for _, s := range res.Songs {
+		if s.Divider {
+			fmt.Fprintf(tw, "\t%d\t\t== %s ==\t\n", s.StartPage, s.Title)
+			continue
+		}
+		n++
+		pages := fmt.Sprint(s.StartPage)
+		if s.Pages > 1 {
+			pages = fmt.Sprintf("%d-%d", s.StartPage, s.StartPage + s.Pages - 1)
+		}
+		title := s.Title
+		if s.PadBefore > 0 {
+			pages += " (after blank)"
+		}
+		if res.Part != "" && !samePart(s.Part, res.Part) && s.Level > 0 {
+			title += fmt.Sprintf(" (%s version)", versionName(s.Part))
+		}
+		if s.Ambiguous {
+			title += " (ambiguous)"
+		}
+		var files []string
+		for _, p := range s.Paths {
+			files = append(files, reportPath(s.Folder, p, s.Selection))
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%s\t%s\n",
+		            n, pages, s.Level, title, strings.Join(files, ", "))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if len(res.Missing) > 0 {
+		fmt.Fprintln(w, "\nMissing:")
+		for _, m := range res.Missing {
+			fmt.Fprintf(w, "  %s\n", m)
+		}
+	}
+	if len(res.Warnings) > 0 {
+		fmt.Fprintln(w, "\nWarnings:")
+		for _, m := range res.Warnings {
+			fmt.Fprintf(w, "  %s\n", m)
+		}
+	}
+	return nil
+}
+
+// SaveReport writes the report of the songbook res to the file at
+// path, as JSON if the filename ends with ».json«, else as text.
+func SaveReport(path string, res *Result) error {
+	fh, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	asJSON := strings.EqualFold(filepath.Ext(path), ".json")
+	if err := WriteReport(fh, res, asJSON); err != nil {
+		fh.Close()
+		return err
+	}
+	return fh.Close()
+}
+
+// reportPath shortens the path p of a PDF file for the text report
+// to start with the name of its folder (or the folder's subfolder),
+// and adds the page selection sel, if any.
+func reportPath(folder, p, sel string) string {
+	if rel, err := filepath.Rel(filepath.Dir(folder), p); err == nil && folder != "" {
+		p = rel
+	}
+	if sel != "" {
+		p += fmt.Sprintf(" [pages %s]", sel)
+	}
+	return p
+}
@@ -0,0 +1,38 @@
+package songbook
+
+import(
+	"fmt"
+	"github.com/pdfcpu/pdfcpu/pkg/font"
+)
+
+// Largest font size for the text on a divider page. Longer texts
+// get smaller to fit the width of the page.
+const dividerFontSize = 60
+
+// isDivider reports whether s is a divider rather than a song.
+func isDivider(s Song) bool {
+	return s.Divider
+}
+
+// songCount returns the number of songs, not counting dividers.
+func songCount(songs []Song) int {
+	var n int
+	for _, s := range songs {
+		if !s.Divider {
+			n++
+		}
+	}
+	return n
+}
+
+// WriteDivider creates a PDF file at path with the divider page for
+// the section of a songbook called title: just the title in large
+// type in the middle of the page.
+func WriteDivider(title, path string) error {
+	size := min(dividerFontSize,
+	            font.Size(title, textFont(title, boldFont),
+	                      pageWidth - 2 * pageMargin))
+	fmt.Printf("Writing divider page for %s\n", title)
+	return createPages([][]pageText{{{Value: title, Anchor: "center",
+	                                  Font: pageFont{boldFont, size}}}}, path)
+}
@@ -0,0 +1,57 @@
+package songbook
+
+import(
+	"fmt"
+	"os"
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+// pageSelection turns a page selection from a playlist entry, like
+// "3" or "2-4,6", into the form pdfcpu expects. An empty selection
+// means all pages and results in nil.
+func pageSelection(sel string) ([]string, error) {
+	ps, err := api.ParsePageSelection(sel)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid page selection %q: %w",
+		                       ErrPlaylist, sel, err)
+	}
+	return ps, nil
+}
+
+// selectedPageCount returns the number of pages that the selection
+// sel takes from a PDF file with n pages. An empty selection takes
+// all pages.
+func selectedPageCount(n int, sel string) (int, error) {
+	if sel == "" {
+		return n, nil
+	}
+	ps, err := pageSelection(sel)
+	if err != nil {
+		return 0, err
+	}
+	pages, err := api.PagesForPageCollection(n, ps)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid page selection %q for %d page(s): %w",
+		                     ErrPlaylist, sel, n, err)
+	}
+	return len(pages), nil
+}
+
+// extractPages writes the pages selected by sel from the PDF file at
+// path into a new temporary file and returns its path. The source
+// file is not changed. The caller removes the temporary file.
+func extractPages(path, sel string) (string, error) {
+	ps, err := pageSelection(sel)
+	if err != nil {
+		return "", err
+	}
+	tmpPath, err := tempPdfPath("pages")
+	if err != nil {
+		return "", err
+	}
+	if err := api.CollectFile(path, tmpPath, ps, nil); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("%w: %s, pages %s: %w", ErrPDF, path, sel, err)
+	}
+	return tmpPath, nil
+}
@@ -0,0 +1,318 @@
+package songbook
+
+import(
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"slices"
+	"errors"
+	"regexp"
+	"strings"
+	"net/http"
+	"path/filepath"
+	"html/template"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+// partNameRE describes the instrument parts that may be asked for
+// in a URL.
+var partNameRE = regexp.MustCompile(`\A[\p{L}\p{N}_]+\z`)
+
+// Server makes the Songbooks of a Base Path available over HTTP,
+// e.g. for tablets on the Wi-Fi of a venue:
+//
+//	/                    the Projects with their Playlists
+//	/book/{name}         the Songbook for the Playlist name (like
+//	                     »TheKeltners-tour.txt«, or »TheKeltners-abc«
+//	                     for all songs), with missing titles etc.
+//	/pdf/{name}          the PDF file of this Songbook
+//
+// Both /book and /pdf take the instrument part as »?part=bass«. The
+// Songbook is built on each request, which is cheap if nothing has
+// changed (see Manifest). It is written to the Base Path, just like
+// by the command songbook. Server is an http.Handler, so it can be
+// run with http.ListenAndServe or tested with httptest.
+type Server struct {
+	BasePath  string  // Folder with the Project Folders
+	ListDir   string  // Folder with the Playlists
+	GenPdPath string  // Folder with generic PDF files
+	Options   Options // Features of the Songbooks (no DryRun)
+	mux       *http.ServeMux
+	building  sync.Mutex // Held while building a Songbook, see handlePdf
+}
+
+// NewServer returns a Server for the Base Path bp with the Playlists
+// in listDir and the generic PDF files in genPdPath, building
+// Songbooks with opts.
+func NewServer(bp, listDir, genPdPath string, opts Options) *Server {
+	// pdfcpu loads its configuration on first use, which must not
+	// happen in several requests at once.
+	model.NewDefaultConfiguration()
+	s := &Server{BasePath: bp, ListDir: listDir, GenPdPath: genPdPath,
+	             Options: opts, mux: http.NewServeMux()}
+	s.mux.HandleFunc("GET /{$}", s.handleIndex)
+	s.mux.HandleFunc("GET /book/{name}", s.handleBook)
+	s.mux.HandleFunc("GET /pdf/{name}", s.handlePdf)
+	return s
+}
+
+// ServeHTTP answers a request, see Server.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// serverProject is a Project as listed on the index page: its
+// Playlists, and whether it has a Project Folder (for the
+// alphabetic Songbook).
+type serverProject struct {
+	Name      string
+	Playlists []string
+	HasFolder bool
+}
+
+// projects returns the Projects of the Base Path: those with
+// Playlists and those with a Project Folder, sorted by name.
+func (s *Server) projects() ([]serverProject, error) {
+	lists, err := FindPlaylists(s.ListDir, "")
+	if err != nil {
+		return nil, err
+	}
+	byName := map[string]*serverProject{}
+	var names []string
+	project := func(name string) *serverProject {
+		if byName[name] == nil {
+			byName[name] = &serverProject{Name: name}
+			names = append(names, name)
+		}
+		return byName[name]
+	}
+	for _, l := range lists {
+		p, _, _ := ParseListName(l) // FindPlaylists has checked them
+		project(p).Playlists = append(project(p).Playlists, l)
+	}
+	entries, err := os.ReadDir(s.BasePath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFolder, err)
+	}
+	for _, e := range entries {
+		path := filepath.Join(s.BasePath, e.Name())
+		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") ||
+		   path == filepath.Clean(s.ListDir) ||
+		   path == filepath.Clean(s.GenPdPath) {
+			continue
+		}
+		project(e.Name()).HasFolder = true
+	}
+	slices.Sort(names)
+	var ps []serverProject
+	for _, n := range names {
+		ps = append(ps, *byName[n])
+	}
+	return ps, nil
+}
+
+// errNotFound is returned by build for a Songbook that is not there
+// to be built.
+var errNotFound = errors.New("no such Songbook")
+
+// build builds the Songbook name (see Server) for the instrument
+// part (if any). Only Playlists in the Playlist folder and Project
+// Folders in the Base Path are taken, so a name cannot reach out of
+// them. The caller holds s.building, as two requests for the same
+// Songbook would write the same file, or one would write it while
+// the other copies it for sending (see handlePdf).
+func (s *Server) build(name, part string) (*Result, error) {
+	project, context, err := ParseListName(name)
+	if err != nil || (part != "" && !partNameRE.MatchString(part)) {
+		return nil, errNotFound
+	}
+	pdPath := filepath.Join(s.BasePath, project)
+	outPath := filepath.Join(s.BasePath,
+	                         fmt.Sprintf("%s-%s.pdf", project, context))
+	if part != "" {
+		outPath = strings.TrimSuffix(outPath, ".pdf") + "-" + part + ".pdf"
+	}
+	opts := s.Options
+	opts.DryRun, opts.Part = false, part
+	opts.Cover.Project, opts.Cover.Context = project, context
+
+	if context == "abc" {
+		st, err := os.Stat(pdPath)
+		if name != project + "-abc" || err != nil || !st.IsDir() {
+			return nil, errNotFound
+		}
+		return SongbookByAbc(pdPath, outPath, opts)
+	}
+	lists, err := FindPlaylists(s.ListDir, project)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(lists, name) {
+		return nil, errNotFound
+	}
+	return SongbookByList(filepath.Join(s.ListDir, name), pdPath, s.GenPdPath,
+	                      outPath, opts)
+}
+
+// fail answers a request with the error err, as plain text.
+func (s *Server) fail(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	if errors.Is(err, errNotFound) {
+		code = http.StatusNotFound
+	}
+	http.Error(w, err.Error(), code)
+}
+
+// handleIndex lists the Projects with their Songbooks.
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	ps, err := s.projects()
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+	s.render(w, indexTemplate, ps)
+}
+
+// handleBook builds a Songbook and shows what is in it and what is
+// missing, with a link to the PDF file.
+func (s *Server) handleBook(w http.ResponseWriter, r *http.Request) {
+	name, part := r.PathValue("name"), r.URL.Query().Get("part")
+	s.building.Lock()
+	res, err := s.build(name, part)
+	s.building.Unlock()
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+	project, _, _ := ParseListName(name)
+	pc, err := ReadProjectConfig(filepath.Join(s.BasePath, project))
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+	pdfURL := "/pdf/" + name
+	if part != "" {
+		pdfURL += "?part=" + part
+	}
+	s.render(w, bookTemplate, struct {
+		Name   string
+		Part   string
+		Parts  []string
+		PdfURL string
+		Songs  int
+		Res    *Result
+	}{name, part, pc.Parts.Names, pdfURL, songCount(res.Songs), res})
+}
+
+// handlePdf builds a Songbook and sends its PDF file. The file is
+// copied while no other request can write it, so that sending it
+// (maybe slowly, over the Wi-Fi of a venue) does not hold up other
+// requests.
+func (s *Server) handlePdf(w http.ResponseWriter, r *http.Request) {
+	s.building.Lock()
+	res, err := s.build(r.PathValue("name"), r.URL.Query().Get("part"))
+	var fh *os.File
+	if err == nil {
+		fh, err = snapshot(res.OutPath)
+	}
+	s.building.Unlock()
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+	defer os.Remove(fh.Name())
+	defer fh.Close()
+	st, err := fh.Stat()
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+	w.Header().Set("Content-Disposition",
+	               fmt.Sprintf("inline; filename=%q", filepath.Base(res.OutPath)))
+	http.ServeContent(w, r, filepath.Base(res.OutPath), st.ModTime(), fh)
+}
+
+// snapshot copies the file at path to a new temporary file and
+// returns it, open for reading from the start. The caller closes
+// and removes it.
+func snapshot(path string) (*os.File, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrPDF, err)
+	}
+	defer src.Close()
+	fh, err := os.CreateTemp("", "songbook-*.pdf")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrPDF, err)
+	}
+	if _, err = io.Copy(fh, src); err == nil {
+		_, err = fh.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		fh.Close()
+		os.Remove(fh.Name())
+		return nil, fmt.Errorf("%w: %w", ErrPDF, err)
+	}
+	return fh, nil
+}
+
+// render writes the page made by t from data.
+func (s *Server) render(w http.ResponseWriter, t *template.Template, data any) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := t.ExecuteTemplate(w, "page", data); err != nil {
+		fmt.Printf("Cannot render page: %s\n", err)
+	}
+}
+
+// pageTemplate is the frame of all pages, made to be read on
+// tablets and phones.
+const pageTemplate = `{{define "page"}}<!DOCTYPE html>
+<html><head><meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Songbooks</title>
+<style>
+body { font-family: sans-serif; margin: 1em auto; max-width: 40em;
+       padding: 0 1em; line-height: 1.5; }
+a { color: #0645ad; }
+li { margin: .4em 0; }
+.download { display: inline-block; padding: .6em 1.2em; margin: .5em 0;
+            background: #0645ad; color: white; text-decoration: none;
+            border-radius: .3em; font-size: 1.2em; }
+.missing { color: #b00; }
+</style></head>
+<body>{{template "body" .}}</body></html>{{end}}`
+
+var indexTemplate = template.Must(template.Must(
+	template.New("index").Parse(pageTemplate)).Parse(`{{define "body"}}
+<h1>Songbooks</h1>
+{{range .}}<h2>{{.Name}}</h2>
+<ul>
+{{range .Playlists}}<li><a href="/book/{{.}}">{{.}}</a></li>
+{{end}}{{if .HasFolder}}<li><a href="/book/{{.Name}}-abc">All songs (A–Z)</a></li>
+{{end}}</ul>
+{{else}}<p>No Projects found.</p>
+{{end}}{{end}}`))
+
+var bookTemplate = template.Must(template.Must(
+	template.New("book").Parse(pageTemplate)).Parse(`{{define "body"}}
+<p><a href="/">All Songbooks</a></p>
+<h1>{{.Name}}{{if .Part}} ({{.Part}}){{end}}</h1>
+{{if .Parts}}<p>Parts: <a href="/book/{{.Name}}">all</a>
+{{range .Parts}} · <a href="/book/{{$.Name}}?part={{.}}">{{.}}</a>{{end}}</p>
+{{end}}<p><a class="download" href="{{.PdfURL}}">Download PDF</a><br>
+{{.Res.Pages}} page(s), {{.Songs}} song(s){{if .Res.UpToDate}}, up to date{{end}}</p>
+{{if .Res.Missing}}<h2 class="missing">Missing</h2>
+<ul class="missing">
+{{range .Res.Missing}}<li>{{.}}</li>
+{{end}}</ul>
+{{end}}{{if .Res.Warnings}}<h2>Warnings</h2>
+<ul>
+{{range .Res.Warnings}}<li>{{.}}</li>
+{{end}}</ul>
+{{end}}<h2>Songs</h2>
+<ol>
+{{range .Res.Songs}}{{if .Divider}}</ol><h3>{{.Title}}</h3><ol>
+{{else}}<li>{{.Title}} <small>(page {{.StartPage}})</small></li>
+{{end}}{{end}}</ol>
+{{end}}`))
@@ -0,0 +1,124 @@
+package songbook
+
+import(
+	"os"
+	"strings"
+	"testing"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+)
+
+// newTestServer returns a Server for a new Base Path with the
+// Project »Band« (two PDF files, one Playlist with a title that is
+// missing) and an Original folder with one more PDF file.
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	bp := t.TempDir()
+	listDir := filepath.Join(bp, "playlists")
+	genPdPath := filepath.Join(bp, "Original")
+	pdfs := map[string][]string{
+		"Band":     {"AutumnLeaves.pdf", "Yesterday-guitar.pdf"},
+		"Original": {"BrownEyedGirl.pdf"},
+	}
+	for dir, names := range pdfs {
+		if err := os.MkdirAll(filepath.Join(bp, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+		for _, n := range names {
+			page := []pageText{{Value: n, Anchor: "center",
+			                    Font: pageFont{defaultFont, 24}}}
+			if err := createPages([][]pageText{page},
+			                      filepath.Join(bp, dir, n)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := os.MkdirAll(listDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	list := "Autumn Leaves\nBrown Eyed Girl\nNo Such Song\n"
+	if err := os.WriteFile(filepath.Join(listDir, "Band-gig.txt"),
+	                       []byte(list), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return NewServer(bp, listDir, genPdPath, Options{})
+}
+
+// get answers a GET request for url with s.
+func get(s *Server, url string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+	return rec
+}
+
+func TestServerIndex(t *testing.T) {
+	s := newTestServer(t)
+	rec := get(s, "/")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET / = %d, want %d", rec.Code, http.StatusOK)
+	}
+	for _, want := range []string{"Band", `href="/book/Band-gig.txt"`,
+	                              `href="/book/Band-abc"`} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("GET / does not list %s:\n%s", want, rec.Body)
+		}
+	}
+}
+
+func TestServerBook(t *testing.T) {
+	s := newTestServer(t)
+	rec := get(s, "/book/Band-gig.txt")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /book/Band-gig.txt = %d, want %d:\n%s",
+		         rec.Code, http.StatusOK, rec.Body)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{"Autumn Leaves", "Brown Eyed Girl",
+	                              "Missing", "No Such Song",
+	                              `href="/pdf/Band-gig.txt"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("GET /book/Band-gig.txt does not show %s:\n%s", want, body)
+		}
+	}
+}
+
+func TestServerPdf(t *testing.T) {
+	s := newTestServer(t)
+	for _, url := range []string{"/pdf/Band-gig.txt", "/pdf/Band-abc",
+	                             "/pdf/Band-gig.txt?part=guitar"} {
+		rec := get(s, url)
+		if rec.Code != http.StatusOK {
+			t.Errorf("GET %s = %d, want %d:\n%s",
+			         url, rec.Code, http.StatusOK, rec.Body)
+			continue
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "application/pdf" {
+			t.Errorf("GET %s: Content-Type %q, want application/pdf", url, ct)
+		}
+		if !strings.HasPrefix(rec.Body.String(), "%PDF-") {
+			t.Errorf("GET %s: no PDF file sent", url)
+		}
+	}
+}
+
+func TestServerNotFound(t *testing.T) {
+	s := newTestServer(t)
+	for _, url := range []string{
+		"/book/Band-tour.txt",           // No such Playlist
+		"/pdf/Other-gig.txt",            // No such Project
+		"/book/Nowhere-abc",             // No such Project Folder
+		"/book/Band-gig",                // No Playlist name
+		"/pdf/..%2FBand-gig.txt",        // Out of the Playlist folder
+		"/book/..%2F..%2Fetc-passwd.txt",
+		"/pdf/Band-..%2F..%2Fx.txt",
+		"/pdf/Band-gig.txt?part=..%2Fx", // Bad part names
+		"/book/Band-gig.txt?part=a-b",
+		"/pdf/Band-gig.txt?part=gui+tar",
+		"/nothing",
+	} {
+		if rec := get(s, url); rec.Code != http.StatusNotFound {
+			t.Errorf("GET %s = %d, want %d", url, rec.Code, http.StatusNotFound)
+		}
+	}
+}
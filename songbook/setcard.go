@@ -0,0 +1,158 @@
+package songbook
+
+import(
+	"fmt"
+	"strings"
+	"path/filepath"
+	"github.com/pdfcpu/pdfcpu/pkg/font"
+)
+
+// Layout of set cards. The font gets as large as cardMaxFontSize
+// if the set fits, and is reduced to fit the whole set onto one
+// page, but not below cardMinFontSize; longer sets take several
+// pages.
+const (
+	cardMaxFontSize = 48
+	cardMinFontSize = 24
+	cardLineSpacing = 1.3 // Line height by font size
+)
+
+// SetCardOptions controls the set card, i.e. the playlist printed
+// in large type (e.g. to be taped to the floor). Numbers, Keys and
+// Tempos add the number of each song, and the key and the tempo
+// given in a structured playlist; Sections adds the section
+// headings. With Prepend set, the set card is put in front of the
+// songbook (see SongbookByList).
+type SetCardOptions struct {
+	Prepend  bool
+	Numbers  bool
+	Keys     bool
+	Tempos   bool
+	Sections bool
+}
+
+// cardLine is one line of a set card: the title (with its number)
+// on the left, key and tempo on the right, or a section heading.
+type cardLine struct {
+	left    string
+	right   string
+	section bool
+}
+
+// unitWidth returns the width of the line for a font size of 1.
+func (l cardLine) unitWidth() float64 {
+	if l.section {
+		return font.TextWidth(l.left, textFont(l.left, defaultFont), 100) / 100
+	}
+	w := font.TextWidth(l.left, textFont(l.left, boldFont), 100) / 100
+	if l.right != "" {
+		w += 1 + font.TextWidth(l.right, textFont(l.right, defaultFont),
+		                        100) / 100 // 1 em gap
+	}
+	return w
+}
+
+// setCardLines returns the lines of the set card for the playlist
+// entries.
+func setCardLines(entries []Entry, sc SetCardOptions) []cardLine {
+	var lines []cardLine
+	var n int
+	for _, e := range entries {
+		if e.Section != "" {
+			if sc.Sections {
+				lines = append(lines, cardLine{left: strings.ToUpper(e.Section),
+				                               section: true})
+			}
+			continue
+		}
+		t := e.Title
+		if t == "" && e.File != "" {
+			t = titleFromFilename(filepath.Base(e.File))
+		}
+		if t == "" {
+			continue
+		}
+		n++
+		if sc.Numbers {
+			t = fmt.Sprintf("%d. %s", n, t)
+		}
+		var right []string
+		if sc.Keys && e.Key != "" {
+			right = append(right, e.Key)
+		}
+		if sc.Tempos && e.Tempo != "" {
+			right = append(right, e.Tempo)
+		}
+		lines = append(lines, cardLine{left: t, right: strings.Join(right, "  ")})
+	}
+	return lines
+}
+
+// setCardPages lays out the set card for the playlist entries and
+// returns its pages, see createPages. The font size is chosen so
+// that the longest line fits the width of a page and, if possible
+// with cardMinFontSize, all lines fit on one page.
+func setCardPages(entries []Entry, sc SetCardOptions) [][]pageText {
+	lines := setCardLines(entries, sc)
+	width := pageWidth - 2 * pageMargin
+	height := pageHeight - 2 * pageMargin
+	widthFit := float64(cardMaxFontSize)
+	for _, l := range lines {
+		if w := l.unitWidth(); w > 0 {
+			widthFit = min(widthFit, width / w)
+		}
+	}
+	size := widthFit
+	if len(lines) > 0 {
+		size = min(size, height / (float64(len(lines)) * cardLineSpacing))
+	}
+	if size < cardMinFontSize {
+		size = min(widthFit, cardMinFontSize) // More than one page
+	}
+	fs := max(1, int(size))
+	lineHeight := float64(fs) * cardLineSpacing
+	perPage := max(1, int(height / lineHeight))
+	var pages [][]pageText
+	for first := 0; first < len(lines) || first == 0; first += perPage {
+		var texts []pageText
+		y := pageHeight - pageMargin - float64(fs)
+		for _, l := range lines[first:min(first + perPage, len(lines))] {
+			if l.section {
+				texts = append(texts,
+					pageText{Value: l.left, Pos: [2]float64{pageMargin, y},
+					         Font: pageFont{defaultFont, fs}})
+			} else {
+				texts = append(texts,
+					pageText{Value: l.left, Pos: [2]float64{pageMargin, y},
+					         Font: pageFont{boldFont, fs}})
+				if l.right != "" {
+					texts = append(texts,
+						pageText{Value: l.right,
+						         Pos: [2]float64{pageWidth - pageMargin, y},
+						         Align: "Right", Font: pageFont{defaultFont, fs}})
+				}
+			}
+			y -= lineHeight
+		}
+		pages = append(pages, texts)
+	}
+	fmt.Printf("Set card: %d line(s) in %d pt on %d page(s)\n",
+	           len(lines), fs, len(pages))
+	return pages
+}
+
+// SetCardByList writes the set card for the playlist at listPath to
+// outPath, as requested by sc. The songs are listed as they are in
+// the playlist; no PDF files are needed. It returns the number of
+// pages of the set card.
+func SetCardByList(listPath, outPath string, sc SetCardOptions) (int, error) {
+	entries, err := ReadEntries(listPath)
+	if err != nil {
+		return 0, err
+	}
+	pages := setCardPages(entries, sc)
+	if err := createPages(pages, outPath); err != nil {
+		return 0, err
+	}
+	return len(pages), nil
+}
@@ -6,24 +6,168 @@ package songbook
 
 
 import(
-	"log"
 	"fmt"
 	"strings"
 	"os"
 	"regexp"
 	"bufio"
 	"path/filepath"
+	"io/fs"
+	"sort"
+	"slices"
 	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
 )
 
 // essenceRE is a regular expression that describes the characters
 // that will be taken out before matching a playlist entry (a title)
 // against a PDF filename to decide if that PDF file is the sheet
 // music for this title.
-// Characters not to be considered are everything except Latin letters
-// and (Arabic) digits. This leaves non-English letters in. To avoid
+// Characters not to be considered are everything except letters of
+// any script (with their combining marks) and (Arabic) digits. This
+// leaves Cyrillic, Greek, Japanese etc. titles intact. To avoid
 // trouble with exotic digits we do not use \p{N} for numeric characters.
-var essenceRE = regexp.MustCompile(`[^\p{Latin}\d]`)
+var essenceRE = regexp.MustCompile(`[^\p{L}\p{M}\d]`)
+
+// pdNameRE describes the names of PDF files to consider. Other
+// files (and PDF files with names starting with a dot etc.) are
+// skipped.
+// var pdNameRE = regexp.MustCompile(`(?i)\A[A-Za-z0-9][-\w]*\.pdf`)
+var pdNameRE = regexp.MustCompile(`(?i)\A[\p{L}\p{N}][\p{L}\p{M}\p{N}_\\-]*\.pdf`)
+
+// camelRE finds the word boundaries in CamelCase filenames, i.e.
+// a lowercase letter followed by an uppercase letter.
+var camelRE = regexp.MustCompile(`(\p{Ll})(\p{Lu})`)
+
+// Song is one entry of a songbook: a title and the PDF file(s)
+// with its sheet music. Folder is the folder the files were found
+// in, and Level its position in the SearchChain (starting at 1; 0
+// for files named explicitly). Part is the instrument part of the
+// files (see Options.Part), which may be a fallback like "full".
+// Ambiguous is set if several files matched the title equally well
+// (and all were taken). Selection optionally restricts the pages
+// taken from each of these files (like "2-3"); Notes are remarks
+// from the playlist. Pages is the total number of (selected) pages
+// and StartPage the page number in the songbook where the song
+// begins. PadBefore is the number of blank pages inserted before
+// the song (see Options.Spread). These are filled in by
+// numberPages.
+// A Song with Divider set is no song, but the divider page at the
+// start of a section of the songbook, showing the Title.
+type Song struct {
+	Title     string   `json:"title"`
+	Paths     []string `json:"files"`
+	Folder    string   `json:"folder"`
+	Level     int      `json:"level"`
+	Part      string   `json:"part,omitempty"`
+	Ambiguous bool     `json:"ambiguous,omitempty"`
+	Divider   bool     `json:"divider,omitempty"`
+	Selection string   `json:"selection,omitempty"`
+	Notes     string   `json:"notes,omitempty"`
+	Pages     int      `json:"pages"`
+	StartPage int      `json:"startPage"`
+	PadBefore int      `json:"padBefore,omitempty"`
+}
+
+// Options controls optional features of a songbook. The zero value
+// creates a songbook with just the merged sheet music.
+type Options struct {
+	TOC       bool           // Table of contents with page numbers at the front
+	Stamp     StampOptions   // Page numbers and titles on every page
+	Strict    bool           // Match titles by substring only, see MatchTitle
+	Metadata  bool           // Match titles also against PDF metadata
+	IndexPath string         // File of the library index, see Index
+	Recursive bool           // Include subdirectories of the folders
+	Exclude   []string       // Patterns of subdirectories to leave out
+	Search    []string       // Folders to search after the project, see SearchChain
+	DryRun    bool           // Resolve titles and count pages, but write no PDF
+	Spread    bool           // Start songs of 2+ pages on a left page, see numberPages
+	Impose    ImposeOptions  // Print copy as booklet or n-up sheets
+	SetCard   SetCardOptions // Set card in front of the songbook
+	Cover     CoverOptions   // Cover page, see also CoverConfig
+	Part      string         // Instrument part to take files for, see partSelector
+	Parts     []string       // All parts built, known as parts besides Part
+	Fallback  []string       // Versions to take for a missing part, like "full"
+	Force     bool           // Build even if up to date, see Manifest
+}
+
+// Result describes a songbook that has been built: OutPath is the
+// file it was written to (or would have been, with DryRun set),
+// Pages its number of pages (PadPages of them blank, see
+// Options.Spread), Part the instrument part (if any), Songs are the
+// playlist entries that were resolved to PDF files (in the order of
+// the songbook, with their pages numbered), Missing are the entries
+// without any PDF file, and Warnings are ambiguous titles, alias
+// problems and the like. ImposedPath is the file with the print
+// copy, if any (see ImposeOptions). UpToDate tells that the
+// songbook was not built again, as nothing has changed since it was
+// built last (see Manifest). See WriteReport for a report of it.
+type Result struct {
+	OutPath     string         `json:"output"`
+	ImposedPath string         `json:"imposed,omitempty"`
+	Part        string         `json:"part,omitempty"`
+	DryRun      bool           `json:"dryRun"`
+	UpToDate    bool           `json:"upToDate,omitempty"`
+	Pages       int            `json:"pages"`
+	PadPages    int            `json:"padPages"`
+	Songs       []Song         `json:"songs"`
+	Missing     []MissingTitle `json:"missing"`
+	Warnings    []string       `json:"warnings"`
+}
+
+// MissingTitle is a playlist entry that no PDF file was found for:
+// its title, the File it names explicitly (if any), and the names
+// of close, but not close enough PDF files. If the file was found,
+// but does not have the Pages asked for (like »Cafe [5]« for a file
+// with 3 pages), File is that file and Pages the selection. Project
+// is set if the entry names a Project Folder that is not there.
+// Part is set if there are files for the title, but neither of the
+// instrument part asked for (see Options.Part) nor of a fallback;
+// Have are the parts there are files for.
+type MissingTitle struct {
+	Title       string   `json:"title"`
+	File        string   `json:"file,omitempty"`
+	Pages       string   `json:"pages,omitempty"`
+	Project     string   `json:"project,omitempty"`
+	Part        string   `json:"part,omitempty"`
+	Have        []string `json:"have,omitempty"`
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+// Messages returns the warnings and a line for each missing title,
+// to be shown to the user at the end of a run.
+func (r *Result) Messages() []string {
+	messages := append([]string{}, r.Warnings...)
+	for _, m := range r.Missing {
+		messages = append(messages, m.String())
+	}
+	return messages
+}
+
+// String describes the missing title for the user, with the close
+// candidates, if any.
+func (m MissingTitle) String() string {
+	msg := fmt.Sprintf("No PDF file at all for %s", m.Title)
+	if m.Pages != "" {
+		return fmt.Sprintf("No pages %s in %s for %s", m.Pages, m.File, m.Title)
+	}
+	if m.Project != "" {
+		return fmt.Sprintf("No Project Folder %s for %s", m.Project, m.Title)
+	}
+	if m.Part != "" {
+		return fmt.Sprintf("No %s part for %s (have: %s)", m.Part, m.Title,
+		                   strings.Join(m.Have, ", "))
+	}
+	if m.File != "" {
+		msg = fmt.Sprintf("No PDF file %s for %s", m.File, m.Title)
+	}
+	if len(m.Suggestions) > 0 {
+		msg += fmt.Sprintf(" (close: %s)", strings.Join(m.Suggestions, ", "))
+	}
+	return msg
+}
 
 // SongbookByList is core function 1/2:
 // It compiles a songbook with sheet music sorted by a playlist
@@ -31,64 +175,494 @@ var essenceRE = regexp.MustCompile(`[^\p{Latin}\d]`)
 // to the directory with PDF files, the path to the directory of
 // generic PDF files (i.e. originals not in a specific project folder),
 // and the FQFN of the output file.
-// If a title in the playlist is ambiguous, multiple files will be
-// included. If no file is found in the project folder, we look also
-// in the generic PD folder.
-// If applicable, this method returns a slice of warnings or other
-// messages.
-func SongbookByList(listPath, pdPath, genPdPath, outPath string) []string {
-	var messages []string  // Do we need it? Nothing to add?
-	var titles []string
-	var allPdNames []string = GetAllPdNames(pdPath)
-	var allGenPdNames []string = GetAllPdNames(genPdPath)
-	// var pdNames []string // Names of PDF files to include
-	var pdfPaths []string // Paths to all PDF files for the PDF songbook
-	titles = ReadPlaylist(listPath)
-	for _, t := range titles {
-		pdNamesToAdd := PdNamesForTitle(t, allPdNames)
-		if len(pdNamesToAdd) > 0 {
-			fmt.Printf("Specific PDF file(s) for %s in %s\n", t, pdPath)
-			pdfPathsToAdd := filenamesToPaths(pdPath, pdNamesToAdd)
-			pdfPaths = append(pdfPaths, pdfPathsToAdd...)
-		} else {
-			genPdNamesToAdd := PdNamesForTitle(t, allGenPdNames)
-			if len(genPdNamesToAdd) > 0 {
-				fmt.Printf("Generic PDF file(s) for %s in %s\n", t, genPdPath)
-				genPdfPathsToAdd := filenamesToPaths(genPdPath, genPdNamesToAdd)
-				pdfPaths = append(pdfPaths, genPdfPathsToAdd...)
-			} else {
-				// Due to importance formatted to stand out:
-				fmt.Printf("No PDF file at all for %s\n", t)
-				messages = append(messages,
-				           fmt.Sprintf("No PDF file at all for %s\n", t)) 
+// The playlist is read with ReadEntries, so it may be a text file
+// or a structured (YAML or JSON) playlist.
+// The sheet music for a title is searched in the folders of the
+// SearchChain, level by level: By default, if no file is found in
+// the project folder, we look also in the generic PD folder. The
+// output tells on which level a title was found.
+// In each folder, the title is looked up in the aliases and matched
+// against the filenames (see Folder.Match); the canonical title an
+// alias stands for is searched on the following levels, too. This
+// is done in strict mode if opts.Strict is set, and with
+// opts.Metadata set also against the title and composer in the PDF
+// metadata. The library index at opts.IndexPath is updated on the
+// way. If a title in the playlist is ambiguous, multiple files will
+// be included and a warning is returned.
+// A heading (section entry) adds a divider page, see WriteDivider.
+// An entry that names a Project is looked up in that Project Folder
+// instead of the one at pdPath (before the other levels), and an
+// entry that names a File takes this file without any matching.
+// Optional features like a table of contents are set with opts.
+// Titles without sheet music do not stop the songbook; they are
+// listed in the Result. An error is returned if a playlist, folder
+// or PDF file cannot be read, or the songbook cannot be written; in
+// the latter case (e.g. if no title was found at all) along with
+// the Result, to tell what is missing.
+func SongbookByList(listPath, pdPath, genPdPath, outPath string,
+                    opts Options) (res *Result, err error) {
+	ix, err := OpenIndex(opts.IndexPath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { saveIndex(ix, res) }()
+	res = &Result{OutPath: outPath, DryRun: opts.DryRun, Part: opts.Part}
+	pc, err := ReadProjectConfig(pdPath)
+	if err != nil {
+		return nil, err
+	}
+	ps := newPartSelector(opts, pc.Parts)
+	chain := SearchChain(pdPath, genPdPath, opts, pc.Search)
+	var levels []*Folder // Folders to search, see SearchChain
+	for i, p := range chain {
+		if _, err := os.Stat(p); i > 0 && err != nil {
+			fmt.Printf("Skipping search folder %s: %s\n", p, err)
+			res.Warnings = append(res.Warnings,
+			               fmt.Sprintf("Search folder %s not available", p))
+			continue
+		}
+		f, err := OpenFolder(p, ix, opts)
+		if err != nil {
+			return nil, err
+		}
+		levels = append(levels, f)
+	}
+	entries, err := ReadEntries(listPath)
+	if err != nil {
+		return nil, err
+	}
+	var otherFolders = map[string]*Folder{} // Projects named by entries
+	for _, e := range entries {
+		if e.Section != "" {
+			fmt.Printf("Section: %s\n", e.Section)
+			res.Songs = append(res.Songs, Song{Title: e.Section, Divider: true})
+			continue
+		}
+		t := e.Title
+		if t == "" && e.File != "" {
+			t = titleFromFilename(filepath.Base(e.File))
+		}
+		if t == "" {
+			res.Warnings = append(res.Warnings,
+			               "Playlist entry without title or file")
+			continue
+		}
+		entryLevels := levels
+		if e.Project != "" {
+			projPath := filepath.Join(filepath.Dir(pdPath), e.Project)
+			if st, err := os.Stat(projPath); err != nil || !st.IsDir() {
+				fmt.Printf("No Project Folder %s for %s\n", e.Project, t)
+				res.Missing = append(res.Missing,
+				              MissingTitle{Title: t, Project: e.Project})
+				continue
 			}
+			if otherFolders[projPath] == nil {
+				otherFolders[projPath], err = OpenFolder(projPath, ix, opts)
+				if err != nil {
+					return nil, err
+				}
+			}
+			entryLevels = append([]*Folder{otherFolders[projPath]}, levels[1:]...)
+		}
+		if e.Notes != "" {
+			fmt.Printf("Note for %s: %s\n", t, e.Notes)
+		}
+		song := Song{Title: t, Selection: e.Pages, Notes: e.Notes}
+		if e.File != "" {
+			var dirs []string
+			for _, f := range entryLevels {
+				dirs = append(dirs, f.Path)
+			}
+			p := pinnedPath(e.File, dirs...)
+			if p == "" {
+				fmt.Printf("No PDF file %s for %s\n", e.File, t)
+				res.Missing = append(res.Missing, MissingTitle{Title: t, File: e.File})
+				continue
+			}
+			fmt.Printf("Pinned PDF file for %s: %s\n", t, p)
+			song.Paths = []string{p}
+			song.Folder = filepath.Dir(p)
+			if err := res.addSong(song, ix); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if essence(t) == "" {
+			fmt.Printf("Nothing to match in title %s\n", t)
+			res.Warnings = append(res.Warnings,
+			               fmt.Sprintf("Title %q has no letters or digits to match", t))
+			continue
+		}
+		var suggestions, have []string
+		match := t // Canonical title, once an alias has been resolved
+		for i, f := range entryLevels {
+			m := f.Match(match, opts)
+			match = m.Title
+			if len(m.Names) == 0 {
+				suggestions = append(suggestions, m.Suggestions...)
+				continue
+			}
+			if ps != nil {
+				names := m.Names
+				if m.Names, song.Part = ps.choose(names); len(m.Names) == 0 {
+					fmt.Printf("No %s part for %s in %s\n", opts.Part, t, f.Path)
+					for _, p := range ps.parts(names) {
+						if !slices.Contains(have, p) {
+							have = append(have, p)
+						}
+					}
+					continue
+				}
+				if !samePart(song.Part, opts.Part) {
+					fmt.Printf("Taking %s version of %s for %s\n",
+					           versionName(song.Part), t, opts.Part)
+				}
+			}
+			fmt.Printf("Level %d: PDF file(s) for %s in %s\n", i + 1, t, f.Path)
+			song.Paths = f.Paths(m.Names)
+			song.Folder, song.Level = f.Path, i + 1
+			song.Ambiguous = m.Ambiguous()
+			if err := res.addSong(song, ix); err != nil {
+				return nil, err
+			}
+			if m.Ambiguous() {
+				fmt.Println(ambiguityMessage(t, m))
+				res.Warnings = append(res.Warnings, ambiguityMessage(t, m))
+			}
+			break
+		}
+		if song.Level == 0 && len(have) > 0 {
+			slices.Sort(have)
+			mt := MissingTitle{Title: t, Part: opts.Part, Have: have}
+			fmt.Println(mt)
+			res.Missing = append(res.Missing, mt)
+		} else if song.Level == 0 {
+			// Due to importance formatted to stand out:
+			fmt.Printf("No PDF file at all for %s\n", t)
+			res.Missing = append(res.Missing,
+			              MissingTitle{Title: t, Suggestions: suggestions})
 		}
 	}
-	// MergePdfFiles(pdPath, pdNames, outPath)
-	MergePdfFiles(pdfPaths, outPath)
-	return messages
+	// Only the aliases of the Project Folder are meant for this
+	// songbook; those of shared folders mostly serve other ones.
+	for i, f := range levels {
+		res.Warnings = append(res.Warnings, f.Aliases.Messages(f.Names, i == 0)...)
+	}
+	for _, f := range otherFolders {
+		res.Warnings = append(res.Warnings, f.Aliases.Messages(f.Names, false)...)
+	}
+	front, err := frontMatters(res, entries, pdPath, pc, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := buildSongbook(res, ix, opts, listPath, front); err != nil {
+		return res, err
+	}
+	return res, nil
 }
 
-
 // SongbookByAbc is core function 2/2:
 // It compiles an alphabetic songbook based on a PDF path and a
-// playlist file path. If applicable, it returns a slice of warnings
-// or other messages.
-func SongbookByAbc(pdPath, outPath string) []string { 
-	var messages []string
-	var allPdNames []string = GetAllPdNames(pdPath)
-	var pdNames []string  // Names of all files to be added
+// playlist file path. In a table of contents and in the bookmarks
+// the songs are listed with titles derived from their filenames.
+// Like SongbookByList, it returns the Result or an error.
+func SongbookByAbc(pdPath, outPath string, opts Options) (res *Result, err error) {
+	ix, err := OpenIndex(opts.IndexPath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { saveIndex(ix, res) }()
+	res = &Result{OutPath: outPath, DryRun: opts.DryRun, Part: opts.Part}
+	pc, err := ReadProjectConfig(pdPath)
+	if err != nil {
+		return nil, err
+	}
+	folder, err := OpenFolder(pdPath, ix, opts)
+	if err != nil {
+		return nil, err
+	}
+	var allPdNames []string = folder.Names
+	ps := newPartSelector(opts, pc.Parts)
+	if ps != nil {
+		allPdNames = ps.filter(allPdNames)
+	}
+	// Files from subdirectories go by their filename, not their path:
+	sort.SliceStable(allPdNames, func(i, j int) bool {
+		return filepath.Base(allPdNames[i]) < filepath.Base(allPdNames[j])
+	})
 	for _, fn := range allPdNames {
-		if okForAbcList(fn) {
-			pdNames = append(pdNames, fn)
+		if okForAbcList(filepath.Base(fn)) {
+			song := Song{Title: titleFromFilename(filepath.Base(fn)),
+			             Paths: []string{filepath.Join(pdPath, fn)},
+			             Folder: pdPath, Level: 1}
+			if ps != nil {
+				// Title without the part:
+				stem, part := ps.split(filepath.Base(fn))
+				song.Title, song.Part = titleFromFilename(stem + ".pdf"), part
+			}
+			res.Songs = append(res.Songs, song)
 			fmt.Printf("Adding PDF file:   %s\n", fn)
 		} else {
 			fmt.Printf("Skipping PDF file: %s\n", fn)
 		}
 	}
-	pdfPaths := filenamesToPaths(pdPath, pdNames)
-	MergePdfFiles(pdfPaths, outPath)
-	return messages
+	front, err := frontMatters(res, nil, pdPath, pc, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := buildSongbook(res, ix, opts, "", front); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// saveIndex saves the library index ix. Failing to do so does not
+// spoil the songbook, so this only adds a warning to res (if any).
+func saveIndex(ix *Index, res *Result) {
+	if err := ix.Save(); err != nil {
+		fmt.Printf("Cannot save library index: %s\n", err)
+		if res != nil {
+			res.Warnings = append(res.Warnings,
+			               fmt.Sprintf("Library index not saved: %s", err))
+		}
+	}
+}
+
+// buildSongbook writes the songbook with the sheet music of the
+// songs of res to its OutPath, adding the features requested in
+// opts, and sets its page count. The front matter (cover, set card)
+// goes first. Finally the print copy is written, if requested by
+// opts.Impose. Every song gets a bookmark. Page counts are taken
+// from the index ix. With opts.DryRun set, only the pages are
+// numbered. Unless opts.Force is set, nothing is written if the
+// songbook is up to date with the Playlist at listPath (if any) and
+// the files it is made of, see Manifest.
+func buildSongbook(res *Result, ix *Index, opts Options, listPath string,
+                   front []frontMatter) error {
+	songs, outPath := res.Songs, res.OutPath
+	var pdfPaths []string
+	var bms []pdfcpu.Bookmark
+	var frontPages int // Pages before the first song
+	for _, fm := range front {
+		frontPages += len(fm.pages)
+	}
+	tocPage := frontPages + 1
+	if opts.TOC {
+		frontPages += tocPageCount(len(songs))
+	}
+	if err := numberPages(songs, frontPages + 1, ix, opts.Spread); err != nil {
+		return err
+	}
+	res.Pages, res.PadPages = frontPages, 0
+	for _, s := range songs {
+		res.Pages += s.PadBefore + s.Pages
+		res.PadPages += s.PadBefore
+	}
+	res.Warnings = append(res.Warnings,
+	                      fontWarnings(generatedTexts(songs, front, opts))...)
+	if opts.DryRun {
+		fmt.Printf("Dry run: not writing %s (%d page(s))\n", outPath, res.Pages)
+		return nil
+	}
+	outputs := []string{outPath}
+	if opts.Impose.Mode != "" {
+		res.ImposedPath = imposedPath(outPath, opts.Impose.Mode)
+		outputs = append(outputs, res.ImposedPath)
+	}
+	old := readManifest(manifestPath(outPath))
+	if other := old.otherPlaylist(listPath); other != "" {
+		fmt.Printf("%s was built from %s before\n", outPath, other)
+		res.Warnings = append(res.Warnings,
+		               fmt.Sprintf("Playlists %s and %s both make %s, each " +
+		                           "build replaces the other", other, listPath,
+		                           outPath))
+	}
+	mf, err := newManifest(res, listPath, opts, front, old)
+	if err != nil {
+		return err
+	}
+	if !opts.Force && mf.upToDate(old, outputs...) {
+		fmt.Printf("Up to date, not writing %s\n", outPath)
+		res.UpToDate = true
+		saveManifest(mf, res)
+		return nil
+	}
+	page := 1
+	for _, fm := range front {
+		p, err := tempPdfPath("front")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(p)
+		if err := writePages(fm.pages, p); err != nil {
+			return err
+		}
+		pdfPaths = append(pdfPaths, p)
+		bms = append(bms, pdfcpu.Bookmark{Title: fm.title, PageFrom: page})
+		page += len(fm.pages)
+	}
+	if opts.TOC {
+		tocPath, err := tempPdfPath("toc")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tocPath)
+		if err := WriteTOC(songs, tocPath); err != nil {
+			return err
+		}
+		pdfPaths = append(pdfPaths, tocPath)
+		bms = append(bms, pdfcpu.Bookmark{Title: "Contents", PageFrom: tocPage})
+	}
+	for _, s := range songs {
+		if s.Divider {
+			p, err := tempPdfPath("divider")
+			if err != nil {
+				return err
+			}
+			defer os.Remove(p)
+			if err := WriteDivider(s.Title, p); err != nil {
+				return err
+			}
+			pdfPaths = append(pdfPaths, p)
+			continue
+		}
+		for _, p := range s.Paths {
+			if s.Selection != "" {
+				var err error
+				if p, err = extractPages(p, s.Selection); err != nil {
+					return err
+				}
+				defer os.Remove(p)
+			}
+			pdfPaths = append(pdfPaths, p)
+		}
+	}
+	if err := MergePdfFiles(pdfPaths, outPath); err != nil {
+		return err
+	}
+	if err := insertPadPages(outPath, songs); err != nil {
+		return err
+	}
+	if err := StampPages(outPath, songs, frontPages, opts.Stamp); err != nil {
+		return err
+	}
+	if err := AddBookmarks(outPath, append(bms, songBookmarks(songs)...)); err != nil {
+		return err
+	}
+	if res.ImposedPath != "" {
+		if err := Impose(outPath, res.ImposedPath, opts.Impose); err != nil {
+			return err
+		}
+	}
+	if err := mf.addOutputs(outputs...); err != nil {
+		return fmt.Errorf("%w: %w", ErrPDF, err)
+	}
+	saveManifest(mf, res)
+	return nil
+}
+
+// frontMatter is a generated part at the front of a songbook, like
+// the cover, with the title of its bookmark.
+type frontMatter struct {
+	title string
+	pages []pageContent
+}
+
+// frontMatters returns the front matter for the songbook res of the
+// Project with the Project Folder pdPath, as requested by opts and
+// the project configuration pc: the cover page and the set card for
+// the playlist entries.
+func frontMatters(res *Result, entries []Entry, pdPath string,
+                  pc ProjectConfig, opts Options) ([]frontMatter, error) {
+	var front []frontMatter
+	if opts.Cover.Enabled || pc.Cover.Enabled {
+		cover, err := coverPage(opts.Cover, pc.Cover, pdPath, songCount(res.Songs))
+		if err != nil {
+			return nil, err
+		}
+		front = append(front, frontMatter{"Cover", []pageContent{cover}})
+	}
+	if opts.SetCard.Prepend && entries != nil {
+		front = append(front, frontMatter{"Set List",
+		                                  textPages(setCardPages(entries, opts.SetCard))})
+	}
+	return front, nil
+}
+
+// numberPages counts the (selected) pages of each song's PDF files
+// and sets the songs' Pages and StartPage, assuming that the first
+// song starts on page firstPage of the songbook. The page counts of
+// the files come from the index ix.
+// With spread set, a song of two or more pages that would start on
+// a right (odd) page gets a blank page before it (PadBefore), so it
+// can be read as a spread without turning a page. Like in a book,
+// page 1 is a right page.
+func numberPages(songs []Song, firstPage int, ix *Index, spread bool) error {
+	page := firstPage
+	for i := range songs {
+		songs[i].Pages, songs[i].PadBefore = 0, 0
+		for _, p := range songs[i].Paths {
+			n, err := ix.PageCount(p)
+			if err != nil {
+				return err
+			}
+			if n, err = selectedPageCount(n, songs[i].Selection); err != nil {
+				return fmt.Errorf("%s: %w", songs[i].Title, err)
+			}
+			songs[i].Pages += n
+		}
+		if songs[i].Divider {
+			songs[i].Pages = 1
+		}
+		if spread && songs[i].Pages >= 2 && page % 2 == 1 {
+			songs[i].PadBefore = 1
+			page++
+		}
+		songs[i].StartPage = page
+		page += songs[i].Pages
+	}
+	return nil
+}
+
+// addSong adds s to the songs of res, unless its page selection
+// (see Song.Selection) does not fit its PDF file(s), like
+// »Cafe [5]« for a file with 3 pages: then it is a missing title.
+// Page counts are taken from the index ix.
+func (res *Result) addSong(s Song, ix *Index) error {
+	for _, p := range s.Paths {
+		if s.Selection == "" {
+			break
+		}
+		n, err := ix.PageCount(p)
+		if err != nil {
+			return err
+		}
+		k, err := selectedPageCount(n, s.Selection)
+		if err == nil && k == 0 {
+			err = fmt.Errorf("no pages %q in %d page(s)", s.Selection, n)
+		}
+		if err != nil {
+			fmt.Printf("Leaving out %s: %s\n", s.Title, err)
+			res.Missing = append(res.Missing,
+			              MissingTitle{Title: s.Title, File: filepath.Base(p),
+			                           Pages: s.Selection})
+			return nil
+		}
+	}
+	res.Songs = append(res.Songs, s)
+	return nil
+}
+
+// titleFromFilename derives a song title from the name of a PDF
+// file, for songs that do not come from a playlist:
+// »BeautifulNoise-NeilDiamond.pdf« becomes »Beautiful Noise - Neil
+// Diamond«.
+func titleFromFilename(fn string) string {
+	t := strings.TrimSuffix(fn, filepath.Ext(fn))
+	t = camelRE.ReplaceAllString(t, "$1 $2")
+	t = strings.ReplaceAll(t, "_", " ")
+	t = strings.ReplaceAll(t, "-", " - ")
+	return t
 }
 
 // okForAbcList checks a filename and decides if the file should be
@@ -110,12 +684,15 @@ func okForAbcList(fn string) bool {
 // MergePdfFiles merges the files listed with their filenames in
 // pdNames, located in the folder pdPath, into one PDF file that
 // will be available at outPath.
-func MergePdfFiles(pdfPaths []string, outPath string) {
+func MergePdfFiles(pdfPaths []string, outPath string) error {
+	if len(pdfPaths) == 0 {
+		return fmt.Errorf("%w: no pages for %s", ErrPDF, outPath)
+	}
 	fmt.Println("Merging files")
 	if err := api.MergeCreateFile(pdfPaths, outPath, false, nil); err != nil {
-		fmt.Println("ERROR!")
-		log.Fatal(err)
+		return fmt.Errorf("%w: %w", ErrPDF, err)
 	}
+	return nil
 }
 
 // PdNamesForTitle returns a slice with one or more names of PDF
@@ -130,24 +707,30 @@ func PdNamesForTitle(title string, fns []string) []string {
 	return matches
 }
 
+// listNameRE describes the Project and Context parts of a playlist
+// name.
+var listNameRE = regexp.MustCompile(`(\w+)-(\w+)`)
+
 // ParseListName extracts project name and context from a
-// playlist name and returns those two elements.
-func ParseListName(list string) (string, string) {
-	// Called only once, thus re not to be globally available.
-	// Value is static, thus not using re, err = regexp.Compile().
-	re := regexp.MustCompile(`(\w+)-(\w+)`)
-	m := re.FindStringSubmatch(list)
-	fmt.Printf("%v\n", m)
-	return m[1], m[2] // m[0] is the whole match
+// playlist name and returns those two elements. A name without
+// both parts results in an ErrListName error.
+func ParseListName(list string) (string, string, error) {
+	m := listNameRE.FindStringSubmatch(list)
+	if m == nil {
+		return "", "", fmt.Errorf("%w: %q", ErrListName, list)
+	}
+	return m[1], m[2], nil // m[0] is the whole match
 }
 
 // ReadPlaylist opens the text file (playlist) at the given
 // path and returns a list (slice) of all lines. Empty lines
-// and lines with only whitespace are ignored.
-func ReadPlaylist(path string) []string {
+// and lines with only whitespace are ignored, and so are comment
+// lines starting with # – except for headings starting with ##
+// (like »## Set 2«), see ReadEntries.
+func ReadPlaylist(path string) ([]string, error) {
 	fh, err := os.Open(path)
 	if (err != nil) {
-		log.Fatal(err)
+		return nil, fmt.Errorf("%w: %w", ErrPlaylist, err)
 	}
 	defer fh.Close()
 	scanner := bufio.NewScanner(fh)
@@ -155,27 +738,26 @@ func ReadPlaylist(path string) []string {
 	for scanner.Scan() {
 		line := scanner.Text()
 		tl := strings.TrimSpace(line)
-		if ( tl == "" || strings.HasPrefix(tl, "#") ) {
+		if ( tl == "" ||
+		     strings.HasPrefix(tl, "#") && !headingRE.MatchString(tl) ) {
 			continue
 		}
 		entries = append(entries, line)
 	}
 	if err := scanner.Err(); err != nil {
-		log.Fatal(err)
+		return nil, fmt.Errorf("%w: %s: %w", ErrPlaylist, path, err)
 	}
-	return entries
+	return entries, nil
 }
 
 // GetAllPdNames takes a folder path and returns a list (slice) with
 // the names of all the PDF files in this folder. PDF files are
 // detected by the ".pdf" filename suffix.
-func GetAllPdNames(path string) []string {
+func GetAllPdNames(path string) ([]string, error) {
 	var fns []string // List (Slice) of filenames to return
-	// re := regexp.MustCompile(`(?i)\A[A-Za-z0-9][-\w]*\.pdf`)
-	re := regexp.MustCompile(`(?i)\A[\p{L}\p{N}][\p{L}\p{N}_\\-]*\.pdf`)
 	des, err := os.ReadDir(path) // DirectoryEntrys
 	if (err != nil) {
-		log.Fatal(err)
+		return nil, fmt.Errorf("%w: %w", ErrFolder, err)
 	}
 	for _, de := range des { 
 		fn := de.Name()
@@ -183,29 +765,93 @@ func GetAllPdNames(path string) []string {
 			fmt.Printf("Ignoring subdirectory: %s\n", fn)
 			continue
 		}
-		if re.MatchString(fn) {
+		if pdNameRE.MatchString(fn) {
 			fns = append(fns, fn)
 		} else { 
 			fmt.Printf("Skipping %s\n", fn) // debug
 		}
 	}
-	return fns
+	return fns, nil
+}
+
+// GetAllPdNamesRecursive is like GetAllPdNames, but it also walks
+// through all subdirectories of the folder at path. It returns the
+// paths of the PDF files relative to path, like
+// »Ballads/Yesterday.pdf«. Subdirectories whose name or relative
+// path matches one of the patterns in exclude (see filepath.Match,
+// e.g. »Archive« or »Old*«) are skipped.
+func GetAllPdNamesRecursive(path string, exclude []string) ([]string, error) {
+	var fns []string
+	err := filepath.WalkDir(path, func(p string, de fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(path, p)
+		if err != nil {
+			return err
+		}
+		if de.IsDir() {
+			if rel != "." && excluded(rel, exclude) {
+				fmt.Printf("Excluding subdirectory: %s\n", rel)
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if pdNameRE.MatchString(de.Name()) {
+			fns = append(fns, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFolder, err)
+	}
+	return fns, nil
+}
+
+// excluded reports whether the directory with the relative path rel
+// matches (by its name or its whole relative path) one of patterns.
+func excluded(rel string, patterns []string) bool {
+	for _, pat := range patterns {
+		for _, s := range []string{filepath.Base(rel), filepath.ToSlash(rel)} {
+			if ok, _ := filepath.Match(pat, s); ok {
+				return true
+			}
+		}
+	}
+	return false
 }
 	
 // fileMatch reports whether a (PDF) filename contains to a certain
 // extent a string (song title). Before this check, the two strings
 // are prepared by the essence function to make the check case
 // insensitive and to ignore special characters etc.
+// The check is done once for each of the keyFuncs, so that
+// transliterated or unaccented spellings match as well.
+// A title without any essence (e.g. only punctuation) matches no
+// file at all.
 func fileMatch(filename, title string) bool {
 	// fmt.Printf("Comparing %s with %s\n", essence(filename), essence(title)) // Debug
-	return strings.Contains(essence(filename), essence(title))
+	et, ef := essence(title), essence(filename)
+	if et == "" {
+		return false
+	}
+	for _, key := range keyFuncs {
+		if strings.Contains(key(ef), key(et)) {
+			return true
+		}
+	}
+	return false
 }
 
 // essence extracts the meaningful parts of a string for
 // a fuzzy match. It is used in the fileMatch function.
+// The string is normalized (NFKC) first, so that composed and
+// decomposed letters (as in filenames on macOS) compare equal,
+// and case folded at the end.
 func essence(s string) string {
-	n := strings.ToLower(essenceRE.ReplaceAllString(s, ""))
-	return n
+	n := norm.NFKC.String(s)
+	n = essenceRE.ReplaceAllString(n, "")
+	return cases.Fold().String(n)
 }
 
 // filenamesToPaths turns a slice of filenames into a slice of paths
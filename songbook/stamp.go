@@ -0,0 +1,117 @@
+package songbook
+
+import(
+	"fmt"
+	"strconv"
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// Defaults and distances for stamps, in points.
+const (
+	defaultStampSize = 10
+	stampOffsetX     = 30
+	stampOffsetY     = 20
+)
+
+// StampOptions controls the text that is stamped onto the pages of
+// a songbook after the merge: a running page number in the outer
+// corner and the song title on the opposite side. The zero value
+// stamps nothing.
+type StampOptions struct {
+	PageNumbers bool   // Stamp the page number
+	Titles      bool   // Stamp the song title
+	Position    string // "top" (header) or "bottom" (footer, default)
+	FontSize    int    // Font size in points, default 10
+	FrontPages  bool   // Also stamp pages before the first song
+}
+
+// active reports whether the options ask for any stamp at all.
+func (so StampOptions) active() bool {
+	return so.PageNumbers || so.Titles
+}
+
+// StampPages stamps page numbers and/or song titles, as requested
+// by so, onto the songbook at path. frontPages is the number of
+// pages (table of contents etc.) before the first song. The page
+// numbers are the same as in the table of contents. Divider pages
+// get no title, as they show nothing else.
+func StampPages(path string, songs []Song, frontPages int,
+                so StampOptions) error {
+	if !so.active() {
+		return nil
+	}
+	size := so.FontSize
+	if size <= 0 {
+		size = defaultStampSize
+	}
+	vert, dy := "b", stampOffsetY
+	if so.Position == "top" {
+		vert, dy = "t", -stampOffsetY
+	}
+	m := map[int][]*model.Watermark{}
+	add := func(p int, text, pos string, dx int) error {
+		wm, err := stampText(text, pos, dx, dy, size)
+		if err != nil {
+			return err
+		}
+		m[p] = append(m[p], wm)
+		return nil
+	}
+	if so.PageNumbers && so.FrontPages {
+		for p := 1; p <= frontPages; p++ {
+			outer, dx, _, _ := stampSides(p)
+			if err := add(p, strconv.Itoa(p), vert + outer, dx); err != nil {
+				return err
+			}
+		}
+	}
+	for _, s := range songs {
+		for p := s.StartPage; p < s.StartPage + s.Pages; p++ {
+			outer, dx, inner, idx := stampSides(p)
+			if so.PageNumbers {
+				if err := add(p, strconv.Itoa(p), vert + outer, dx); err != nil {
+					return err
+				}
+			}
+			if so.Titles && !s.Divider {
+				if err := add(p, s.Title, vert + inner, idx); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if len(m) == 0 {
+		return nil
+	}
+	fmt.Printf("Stamping %d page(s)\n", len(m))
+	if err := api.AddWatermarksSliceMapFile(path, path, m, nil); err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrPDF, path, err)
+	}
+	return nil
+}
+
+// stampSides returns the anchors (»l« or »r«) and horizontal
+// offsets of the outer and the inner side of page p: odd pages are
+// right-hand pages with the outer side on the right, even pages are
+// left-hand pages with the outer side on the left.
+func stampSides(p int) (outer string, dx int, inner string, idx int) {
+	if p % 2 == 1 {
+		return "r", -stampOffsetX, "l", stampOffsetX
+	}
+	return "l", stampOffsetX, "r", -stampOffsetX
+}
+
+// stampText returns a text stamp placed at the anchor pos (like
+// "br" for bottom right) and moved by dx and dy points.
+func stampText(text, pos string, dx, dy, size int) (*model.Watermark, error) {
+	desc := fmt.Sprintf("font:%s, points:%d, position:%s, offset:%d %d, " +
+	                    "scale:1 abs, rotation:0, opacity:1, color:#000000",
+	                    textFont(text, defaultFont), size, pos, dx, dy)
+	wm, err := api.TextWatermark(text, desc, true, false, types.POINTS)
+	if err != nil {
+		return nil, fmt.Errorf("%w: stamp %q: %w", ErrPDF, text, err)
+	}
+	return wm, nil
+}
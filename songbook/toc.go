@@ -0,0 +1,65 @@
+package songbook
+
+import(
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// Layout of the table of contents pages.
+const (
+	tocLinesPerPage = 36
+	tocLineHeight   = 18.0
+	tocFontSize     = 12
+	tocHeadSize     = 20
+	tocIndent       = 15.0 // For songs in sections
+)
+
+// tocPageCount returns the number of pages a table of contents
+// for n songs will take.
+func tocPageCount(n int) int {
+	if n == 0 {
+		return 1
+	}
+	return (n + tocLinesPerPage - 1) / tocLinesPerPage
+}
+
+// WriteTOC creates a PDF file at path with the table of contents
+// for songs: one line per song with its title and the page it
+// starts on. Dividers are set in bold, and the songs of their
+// sections indented. The StartPage of each song must already be
+// set, see numberPages.
+func WriteTOC(songs []Song, path string) error {
+	var pages [][]pageText
+	for p := 0; p < tocPageCount(len(songs)); p++ {
+		head := "Contents"
+		if p > 0 {
+			head = "Contents (continued)"
+		}
+		y := pageHeight - pageMargin
+		texts := []pageText{{Value: head, Pos: [2]float64{pageMargin, y},
+		                     Font: pageFont{boldFont, tocHeadSize}}}
+		y -= 2 * tocLineHeight
+		first := p * tocLinesPerPage
+		last := min(first + tocLinesPerPage, len(songs))
+		for i, s := range songs[first:last] {
+			x, fontName := pageMargin, defaultFont
+			if s.Divider {
+				fontName = boldFont
+			} else if slices.ContainsFunc(songs[:first + i], isDivider) {
+				x += tocIndent
+			}
+			texts = append(texts,
+				pageText{Value: s.Title, Pos: [2]float64{x, y},
+				         Font: pageFont{fontName, tocFontSize}},
+				pageText{Value: strconv.Itoa(s.StartPage),
+				         Pos: [2]float64{pageWidth - pageMargin, y},
+				         Align: "Right",
+				         Font: pageFont{fontName, tocFontSize}})
+			y -= tocLineHeight
+		}
+		pages = append(pages, texts)
+	}
+	fmt.Printf("Writing table of contents (%d page(s))\n", len(pages))
+	return createPages(pages, path)
+}
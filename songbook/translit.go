@@ -0,0 +1,93 @@
+package songbook
+
+import(
+	"fmt"
+	"bufio"
+	"os"
+	"strings"
+	"unicode"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Transliterations maps letters to their replacement when a title
+// and a filename are compared, so that »Über« matches »Ueber«. The
+// keys are lowercase (titles and filenames are case folded before).
+// The default has the German rules and a few ligatures; it can be
+// replaced or extended, e.g. with ReadTransliterations, before
+// songbooks are built.
+var Transliterations = map[string]string{
+	"ä": "ae",
+	"ö": "oe",
+	"ü": "ue",
+	"ß": "ss",
+	"æ": "ae",
+	"œ": "oe",
+}
+
+// keyFuncs are the ways to turn an essence into a match key. Two
+// strings match if they match with any one of these functions
+// applied to both: »Über« becomes »ueber« (transliterated) and
+// »uber« (accents removed), so it matches »UeberDenWolken.pdf« as
+// well as »Uber-den-Wolken.pdf«.
+var keyFuncs = []func(string) string{transliterate, stripAccents}
+
+// transliterate applies the Transliterations to s.
+func transliterate(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFC.String(s) {
+		if t, ok := Transliterations[string(r)]; ok {
+			b.WriteString(t)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// stripAccents removes the accents and other diacritics of Latin
+// letters from s by decomposing it (NFD) and dropping the combining
+// marks that follow a Latin letter: »Café« becomes »Cafe«. Marks in
+// other scripts are kept, as they make a different letter, like the
+// dakuten of »が« (»か« with two dots).
+func stripAccents(s string) string {
+	var b strings.Builder
+	var latin bool // Whether the last base letter is Latin
+	for _, r := range norm.NFD.String(s) {
+		if !unicode.Is(unicode.Mn, r) {
+			latin = unicode.Is(unicode.Latin, r)
+		} else if latin {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return norm.NFC.String(b.String())
+}
+
+// ReadTransliterations reads transliteration rules from a text file
+// and adds them to Transliterations. Each line holds one rule like
+// »ø=oe«. Empty lines and lines starting with # are ignored.
+func ReadTransliterations(path string) error {
+	fh, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrConfig, err)
+	}
+	defer fh.Close()
+	scanner := bufio.NewScanner(fh)
+	for scanner.Scan() {
+		tl := strings.TrimSpace(scanner.Text())
+		if tl == "" || strings.HasPrefix(tl, "#") {
+			continue
+		}
+		from, to, ok := strings.Cut(tl, "=")
+		if !ok || strings.TrimSpace(from) == "" {
+			return fmt.Errorf("%w: invalid transliteration rule in %s: %s",
+			                  ErrConfig, path, tl)
+		}
+		from = strings.ToLower(norm.NFC.String(strings.TrimSpace(from)))
+		Transliterations[from] = strings.ToLower(strings.TrimSpace(to))
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrConfig, path, err)
+	}
+	return nil
+}
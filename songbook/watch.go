@@ -0,0 +1,126 @@
+package songbook
+
+import(
+	"fmt"
+	"time"
+	"slices"
+	"strings"
+	"maps"
+	"io/fs"
+	"path/filepath"
+)
+
+// Default timing of a Watcher.
+const (
+	DefaultWatchInterval = time.Second
+	DefaultWatchQuiet    = 2 * time.Second
+)
+
+// fileState is what a Watcher compares to notice a change of a file.
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// Watcher polls files and folders for changes: every Interval, it
+// looks at the files at Paths, and at the files in them if they are
+// folders (with Recursive set also in their subdirectories). Hidden
+// files are left out. As files are often saved or copied in bursts,
+// changes are collected until nothing has changed for Quiet.
+// Polling needs no support by the operating system and works on
+// network drives as well.
+type Watcher struct {
+	Paths     []string
+	Recursive bool
+	Interval  time.Duration
+	Quiet     time.Duration
+	state     map[string]fileState
+}
+
+// NewWatcher returns a Watcher for paths with the default timing,
+// knowing the current state of the files.
+func NewWatcher(paths []string, recursive bool) *Watcher {
+	w := &Watcher{Paths: paths, Recursive: recursive,
+	              Interval: DefaultWatchInterval, Quiet: DefaultWatchQuiet}
+	w.state = w.scan()
+	return w
+}
+
+// Wait blocks until files have changed and then no more for Quiet,
+// and returns the changes, like »changed: CoolBand/Yesterday.pdf«.
+// It returns nil if stop is closed before.
+func (w *Watcher) Wait(stop <-chan struct{}) []string {
+	var changes []string
+	var last time.Time // Time of the last change seen
+	tick := time.NewTicker(w.Interval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case now := <-tick.C:
+			state := w.scan()
+			if c := diffStates(w.state, state); len(c) > 0 {
+				changes = append(changes, c...)
+				last = now
+			}
+			w.state = state
+			if len(changes) > 0 && now.Sub(last) >= w.Quiet {
+				return changes
+			}
+		}
+	}
+}
+
+// scan returns the current state of the watched files.
+func (w *Watcher) scan() map[string]fileState {
+	state := map[string]fileState{}
+	for _, p := range w.Paths {
+		filepath.WalkDir(p, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil // Gone or unreadable: seen as removed
+			}
+			hidden := strings.HasPrefix(d.Name(), ".") && path != p
+			if d.IsDir() {
+				if path != p && (hidden || !w.Recursive) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if hidden {
+				return nil
+			}
+			if info, err := d.Info(); err == nil {
+				state[path] = fileState{info.Size(), info.ModTime()}
+			}
+			return nil
+		})
+	}
+	return state
+}
+
+// diffStates describes the differences between the states old and
+// cur of the watched files, sorted by path.
+func diffStates(old, cur map[string]fileState) []string {
+	var changes []string
+	paths := slices.Sorted(maps.Keys(cur))
+	for p := range old {
+		if _, ok := cur[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+	slices.Sort(paths)
+	for _, p := range paths {
+		o, wasThere := old[p]
+		c, isThere := cur[p]
+		switch {
+		case !wasThere:
+			changes = append(changes, fmt.Sprintf("added: %s", p))
+		case !isThere:
+			changes = append(changes, fmt.Sprintf("removed: %s", p))
+		case o.size != c.size || !o.modTime.Equal(c.modTime):
+			changes = append(changes, fmt.Sprintf("changed: %s", p))
+		}
+	}
+	return changes
+}